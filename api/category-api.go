@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/types"
+	"ntsiris/product-microservice/internal/utils"
+)
+
+// CategoryHandler is an HTTP handler for managing the category tree products are classified in.
+type CategoryHandler struct {
+	store storage.ProductStore // store provides an interface to perform CRUD operations on categories.
+}
+
+// NewCategoryHandler creates a new CategoryHandler with the specified ProductStore.
+func NewCategoryHandler(store storage.ProductStore) *CategoryHandler {
+	return &CategoryHandler{store: store}
+}
+
+// RegisterRoutes registers the category-related routes to the provided router.
+func (handler *CategoryHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("POST /category", makeHTTPHandleFunc(handler.handleCreate))
+	router.HandleFunc("GET /category", makeHTTPHandleFunc(handler.handleRetrieveTree))
+	router.HandleFunc("GET /category/{id}", makeHTTPHandleFunc(handler.handleRetrieve))
+	router.HandleFunc("PUT /category/{id}", makeHTTPHandleFunc(handler.handleUpdate))
+	router.HandleFunc("DELETE /category/{id}", makeHTTPHandleFunc(handler.handleDelete))
+}
+
+// handleCreate handles the creation of a new category, as a root category or under an existing parent.
+func (handler *CategoryHandler) handleCreate(w http.ResponseWriter, r *http.Request) error {
+	categoryPayload := new(service.CategoryPayload)
+	if err := parsePayload(r, categoryPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, categoryPayload); err != nil {
+		return err
+	}
+
+	if err := handler.checkParent(r, categoryPayload); err != nil {
+		return err
+	}
+
+	category := service.NewCategory(categoryPayload)
+	if err := handler.store.CreateCategory(r.Context(), category); err != nil {
+		return storeError(r, "Category not created", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, category)
+}
+
+// handleRetrieveTree retrieves every category, arranged as a tree with the root categories at the top level.
+func (handler *CategoryHandler) handleRetrieveTree(w http.ResponseWriter, r *http.Request) error {
+	categories, err := handler.store.RetrieveCategories(r.Context())
+	if err != nil {
+		return storeError(r, "Error in category retrieval", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, service.BuildCategoryTree(categories))
+}
+
+// handleRetrieve retrieves a single category by its ID and returns it in JSON format.
+func (handler *CategoryHandler) handleRetrieve(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	category, err := handler.retrieveCategory(r, service.CategoryID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, category)
+}
+
+// handleUpdate handles renaming a category, moving it under another parent or replacing its attribute schema.
+// Moving a category under itself or one of its descendants is rejected with a Conflict error, and a schema
+// the attributes of a linked product do not conform to with an Unprocessable Entity error.
+func (handler *CategoryHandler) handleUpdate(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	categoryPayload := new(service.CategoryPayload)
+	if err := parsePayload(r, categoryPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, categoryPayload); err != nil {
+		return err
+	}
+
+	category, err := handler.retrieveCategory(r, service.CategoryID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	if err := handler.checkParent(r, categoryPayload); err != nil {
+		return err
+	}
+
+	service.UpdateCategory(category, categoryPayload)
+	if err := handler.store.UpdateCategory(r.Context(), category); err != nil {
+		return storeError(r, "Category not updated", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, category)
+}
+
+// handleDelete handles the deletion of a category specified by its ID, unlinking it from its products.
+// Categories with subcategories are rejected with a Conflict error.
+func (handler *CategoryHandler) handleDelete(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := handler.store.DeleteCategory(r.Context(), service.CategoryID(requestedID)); err != nil {
+		return storeError(r, "Category not deleted", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// retrieveCategory retrieves a category by its ID from the storage layer, returning a Not Found error if the category does not exist.
+func (handler *CategoryHandler) retrieveCategory(r *http.Request, categoryID service.CategoryID) (*service.Category, error) {
+	category, err := handler.store.RetrieveCategory(r.Context(), categoryID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, storeError(r, "Category not found", err)
+		}
+
+		return nil, storeError(r, "Error in category retrieval", err)
+	}
+
+	return category, nil
+}
+
+// checkParent returns an Unprocessable Entity error if the parent category of the payload does not exist.
+func (handler *CategoryHandler) checkParent(r *http.Request, categoryPayload *service.CategoryPayload) error {
+	if categoryPayload.ParentID == nil {
+		return nil
+	}
+
+	_, err := handler.store.RetrieveCategory(r.Context(), *categoryPayload.ParentID)
+	if errors.Is(err, storage.ErrNotFound) {
+		detail := fmt.Sprintf("Parent category with id %d not found", *categoryPayload.ParentID)
+		return types.NewAPIError(http.StatusUnprocessableEntity, detail, r.URL.Path, err)
+	}
+	if err != nil {
+		return storeError(r, "Error in category retrieval", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ntsiris/product-microservice/internal/mocks"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestCategoryHandler() (*CategoryHandler, *mocks.MockProductStore) {
+	mockStore := mocks.NewMockProductStore()
+	handler := NewCategoryHandler(mockStore)
+	return handler, mockStore
+}
+
+func TestHandleCreateCategory(t *testing.T) {
+	handler, mockStore := setupTestCategoryHandler()
+
+	t.Run("creates root and child categories", func(t *testing.T) {
+		for _, payload := range []string{`{"name": "Electronics"}`, `{"name": "Phones", "parentId": 1}`} {
+			req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+		}
+
+		require.Len(t, mockStore.Categories, 2)
+		require.NotNil(t, mockStore.Categories[2].ParentID)
+		assert.Equal(t, service.CategoryID(1), *mockStore.Categories[2].ParentID)
+	})
+
+	t.Run("returns 400 without a name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(`{"parentId": 1}`))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("creates a category with an attribute schema", func(t *testing.T) {
+		payload := `{"name": "Lighting", "attributeSchema": {"voltage": {"type": "number", "required": true}, "color": {"type": "string"}}}`
+		req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, service.AttributeSchema{
+			"voltage": {Type: service.AttributeNumber, Required: true},
+			"color":   {Type: service.AttributeString},
+		}, mockStore.Categories[3].AttributeSchema)
+		delete(mockStore.Categories, 3)
+	})
+
+	t.Run("returns 400 for an invalid attribute schema", func(t *testing.T) {
+		for payload, fieldErr := range map[string]types.FieldError{
+			`{"name": "Lighting", "attributeSchema": {"voltage": {"type": "volts"}}}`:      {Field: "attributeSchema[voltage].type", Tag: "oneof", Param: "string number boolean"},
+			`{"name": "Lighting", "attributeSchema": {"max voltage": {"type": "number"}}}`: {Field: "attributeSchema[max voltage]", Tag: "attribute"},
+		} {
+			req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+			handlerFunc(rec, req)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, payload)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, []types.FieldError{fieldErr}, problem.Errors, payload)
+		}
+	})
+
+	t.Run("returns 422 for an unknown parent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/category", bytes.NewBufferString(`{"name": "Orphan", "parentId": 99}`))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Len(t, mockStore.Categories, 2)
+	})
+}
+
+func TestHandleRetrieveCategoryTree(t *testing.T) {
+	handler, mockStore := setupTestCategoryHandler()
+
+	t.Run("returns an empty list without categories", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/category", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveTree)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("returns the categories as a tree", func(t *testing.T) {
+		electronics := service.CategoryID(1)
+		mockStore.Categories[1] = &service.Category{ID: 1, Name: "Electronics"}
+		mockStore.Categories[2] = &service.Category{ID: 2, Name: "Phones", ParentID: &electronics}
+		mockStore.Categories[3] = &service.Category{ID: 3, Name: "Books"}
+
+		req := httptest.NewRequest(http.MethodGet, "/category", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveTree)
+		handlerFunc(rec, req)
+
+		var tree []*service.CategoryNode
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&tree))
+		require.Len(t, tree, 2)
+		assert.Equal(t, "Books", tree[0].Name)
+		assert.Equal(t, "Electronics", tree[1].Name)
+		require.Len(t, tree[1].Children, 1)
+		assert.Equal(t, service.CategoryID(2), tree[1].Children[0].ID)
+	})
+}
+
+func TestHandleUpdateCategory(t *testing.T) {
+	handler, mockStore := setupTestCategoryHandler()
+
+	electronics := service.CategoryID(1)
+	mockStore.Categories[1] = &service.Category{ID: 1, Name: "Electronics"}
+	mockStore.Categories[2] = &service.Category{ID: 2, Name: "Phones", ParentID: &electronics}
+	mockStore.Categories[3] = &service.Category{ID: 3, Name: "Books"}
+
+	update := func(id, payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/category/"+id, bytes.NewBufferString(payload))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+		handlerFunc(rec, req)
+		return rec
+	}
+
+	t.Run("renames and moves a category", func(t *testing.T) {
+		rec := update("2", `{"name": "Mobile Phones", "parentId": 3}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "Mobile Phones", mockStore.Categories[2].Name)
+		require.NotNil(t, mockStore.Categories[2].ParentID)
+		assert.Equal(t, service.CategoryID(3), *mockStore.Categories[2].ParentID)
+	})
+
+	t.Run("returns 409 for a move under a descendant", func(t *testing.T) {
+		rec := update("3", `{"name": "Books", "parentId": 2}`)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Nil(t, mockStore.Categories[3].ParentID)
+	})
+
+	t.Run("returns 422 for an unknown parent", func(t *testing.T) {
+		rec := update("2", `{"name": "Phones", "parentId": 99}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	})
+
+	t.Run("returns 422 for a schema a linked product does not conform to", func(t *testing.T) {
+		mockStore.Products[1] = &service.Product{ID: 1, Name: "Novel", Attributes: service.Attributes{"pages": "many"}}
+		mockStore.ProductCategories[1] = []service.CategoryID{3}
+
+		rec := update("3", `{"name": "Books", "attributeSchema": {"pages": {"type": "number"}, "isbn": {"type": "string", "required": true}}}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, `{"productId": 1, "errors": [
+			{"name": "isbn", "tag": "required", "type": "string"},
+			{"name": "pages", "tag": "type", "type": "number"}
+		]}`, string(decodeField(t, rec, "details")))
+		assert.Empty(t, mockStore.Categories[3].AttributeSchema)
+	})
+
+	t.Run("returns 404 for an unknown category", func(t *testing.T) {
+		rec := update("99", `{"name": "Unknown"}`)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestHandleDeleteCategory(t *testing.T) {
+	handler, mockStore := setupTestCategoryHandler()
+
+	electronics := service.CategoryID(1)
+	mockStore.Categories[1] = &service.Category{ID: 1, Name: "Electronics"}
+	mockStore.Categories[2] = &service.Category{ID: 2, Name: "Phones", ParentID: &electronics}
+
+	remove := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/category/"+id, nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleDelete)
+		handlerFunc(rec, req)
+		return rec
+	}
+
+	t.Run("returns 409 for a category with subcategories", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, remove("1").Code)
+		assert.Len(t, mockStore.Categories, 2)
+	})
+
+	t.Run("deletes a category", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, remove("2").Code)
+		assert.NotContains(t, mockStore.Categories, service.CategoryID(2))
+	})
+
+	t.Run("returns 404 for an unknown category", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, remove("2").Code)
+	})
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"net/http"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/types"
+	"ntsiris/product-microservice/internal/utils"
+	"strings"
+	"time"
+)
+
+// ExchangeRateHandler is an HTTP handler for the administration of the exchange rates products are priced with.
+type ExchangeRateHandler struct {
+	store storage.ProductStore // store provides an interface to manage the exchange rates.
+}
+
+// NewExchangeRateHandler creates a new ExchangeRateHandler with the specified ProductStore.
+func NewExchangeRateHandler(store storage.ProductStore) *ExchangeRateHandler {
+	return &ExchangeRateHandler{store: store}
+}
+
+// RegisterRoutes registers the exchange rate administration routes to the provided router.
+func (handler *ExchangeRateHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /admin/exchange-rates", makeHTTPHandleFunc(handler.handleRetrieveAll))
+	router.HandleFunc("PUT /admin/exchange-rates/{base}/{quote}", makeHTTPHandleFunc(handler.handleSave))
+	router.HandleFunc("DELETE /admin/exchange-rates/{base}/{quote}", makeHTTPHandleFunc(handler.handleDelete))
+}
+
+// handleRetrieveAll lists every exchange rate, ordered by base and quote currency.
+func (handler *ExchangeRateHandler) handleRetrieveAll(w http.ResponseWriter, r *http.Request) error {
+	exchangeRates, err := handler.store.RetrieveExchangeRates(r.Context())
+	if err != nil {
+		return storeError(r, "Error in exchange rate retrieval", err)
+	}
+
+	if exchangeRates == nil {
+		exchangeRates = []*service.ExchangeRate{}
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, exchangeRates)
+}
+
+// handleSave creates or replaces the rate from the base to the quote currency of the path, stamping it with the current time.
+func (handler *ExchangeRateHandler) handleSave(w http.ResponseWriter, r *http.Request) error {
+	base, quote, err := parseCurrencyPair(r)
+	if err != nil {
+		return err
+	}
+
+	ratePayload := new(service.ExchangeRatePayload)
+	if err := parsePayload(r, ratePayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, ratePayload); err != nil {
+		return err
+	}
+
+	exchangeRate := &service.ExchangeRate{Base: base, Quote: quote, Rate: ratePayload.Rate, UpdatedAt: time.Now().UTC()}
+	if err := handler.store.SaveExchangeRate(r.Context(), exchangeRate); err != nil {
+		return storeError(r, "Exchange rate not saved", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, exchangeRate)
+}
+
+// handleDelete removes the rate from the base to the quote currency of the path.
+func (handler *ExchangeRateHandler) handleDelete(w http.ResponseWriter, r *http.Request) error {
+	base, quote, err := parseCurrencyPair(r)
+	if err != nil {
+		return err
+	}
+
+	if err := handler.store.DeleteExchangeRate(r.Context(), base, quote); err != nil {
+		return storeError(r, "Exchange rate not deleted", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// parseCurrencyPair parses the base and quote currency path parameters, given as case-insensitive ISO 4217 codes,
+// returning a Bad Request error listing the unsupported currencies or a pair of identical currencies.
+func parseCurrencyPair(r *http.Request) (service.Currency, service.Currency, error) {
+	base := service.Currency(strings.ToUpper(r.PathValue("base")))
+	quote := service.Currency(strings.ToUpper(r.PathValue("quote")))
+
+	var fieldErrs []types.FieldError
+	if !service.IsSupportedCurrency(base) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "base", Tag: "currency"})
+	}
+	if !service.IsSupportedCurrency(quote) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "quote", Tag: "currency"})
+	}
+	if len(fieldErrs) == 0 && base == quote {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: "quote", Tag: "nefield", Param: "base"})
+	}
+
+	if len(fieldErrs) > 0 {
+		apiErr := types.NewAPIError(http.StatusBadRequest, "Invalid currency pair", r.URL.Path, nil)
+		apiErr.Errors = fieldErrs
+		return "", "", apiErr
+	}
+
+	return base, quote, nil
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ntsiris/product-microservice/internal/mocks"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestExchangeRateHandler() (*ExchangeRateHandler, *mocks.MockProductStore) {
+	mockStore := mocks.NewMockProductStore()
+	handler := NewExchangeRateHandler(mockStore)
+	return handler, mockStore
+}
+
+func TestHandleSaveExchangeRate(t *testing.T) {
+	handler, mockStore := setupTestExchangeRateHandler()
+
+	t.Run("saves the rate of the currency pair", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/admin/exchange-rates/eur/usd", bytes.NewBufferString(`{"rate": "1.085"}`))
+		req.SetPathValue("base", "eur")
+		req.SetPathValue("quote", "usd")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSave)
+		handlerFunc(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		exchangeRate, err := mockStore.RetrieveExchangeRate(context.Background(), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, service.Rate(1085000), exchangeRate.Rate)
+		assert.False(t, exchangeRate.UpdatedAt.IsZero())
+	})
+
+	t.Run("returns 400 for an invalid currency pair", func(t *testing.T) {
+		for path, errs := range map[[2]string][]types.FieldError{
+			{"EUR", "XYZ"}: {{Field: "quote", Tag: "currency"}},
+			{"USD", "USD"}: {{Field: "quote", Tag: "nefield", Param: "base"}},
+		} {
+			req := httptest.NewRequest(http.MethodPut, "/admin/exchange-rates/"+path[0]+"/"+path[1], bytes.NewBufferString(`{"rate": 1}`))
+			req.SetPathValue("base", path[0])
+			req.SetPathValue("quote", path[1])
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleSave)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var problem types.APIError
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, errs, problem.Errors)
+		}
+	})
+
+	t.Run("returns 400 for a non-positive rate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/admin/exchange-rates/EUR/GBP", bytes.NewBufferString(`{"rate": "0"}`))
+		req.SetPathValue("base", "EUR")
+		req.SetPathValue("quote", "GBP")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSave)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestHandleRetrieveAllExchangeRates(t *testing.T) {
+	handler, mockStore := setupTestExchangeRateHandler()
+
+	t.Run("returns an empty list without rates", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/exchange-rates", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("lists the stored rates", func(t *testing.T) {
+		require.NoError(t, mockStore.SaveExchangeRate(context.Background(), &service.ExchangeRate{Base: "GBP", Quote: "USD", Rate: 1270000}))
+		require.NoError(t, mockStore.SaveExchangeRate(context.Background(), &service.ExchangeRate{Base: "EUR", Quote: "USD", Rate: 1085000}))
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/exchange-rates", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var exchangeRates []*service.ExchangeRate
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&exchangeRates))
+		require.Len(t, exchangeRates, 2)
+		assert.Equal(t, service.Currency("EUR"), exchangeRates[0].Base)
+		assert.Equal(t, service.Currency("GBP"), exchangeRates[1].Base)
+	})
+}
+
+func TestHandleDeleteExchangeRate(t *testing.T) {
+	handler, mockStore := setupTestExchangeRateHandler()
+	require.NoError(t, mockStore.SaveExchangeRate(context.Background(), &service.ExchangeRate{Base: "EUR", Quote: "USD", Rate: 1085000}))
+
+	t.Run("deletes the rate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/exchange-rates/EUR/USD", nil)
+		req.SetPathValue("base", "EUR")
+		req.SetPathValue("quote", "USD")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleDelete)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, mockStore.ExchangeRates)
+	})
+
+	t.Run("returns 404 for an unknown rate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/exchange-rates/EUR/USD", nil)
+		req.SetPathValue("base", "EUR")
+		req.SetPathValue("quote", "USD")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleDelete)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
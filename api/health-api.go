@@ -0,0 +1,106 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/utils"
+	"sync/atomic"
+	"time"
+)
+
+// HealthHandler is an HTTP handler reporting the liveness and readiness of the service.
+type HealthHandler struct {
+	store        storage.ProductStore // store is the storage layer whose availability determines readiness.
+	shuttingDown atomic.Bool          // shuttingDown fails readiness while the server drains its connections.
+}
+
+// HealthStatus is the JSON report returned by the health endpoints.
+type HealthStatus struct {
+	Status  string         `json:"status"`            // Status is "up" if the service can serve requests; otherwise, "down".
+	Storage *StorageHealth `json:"storage,omitempty"` // Storage describes the storage layer, reported by the readiness endpoint while not shutting down.
+}
+
+// StorageHealth describes the availability and schema state of the storage layer.
+type StorageHealth struct {
+	Status           string  `json:"status"`           // Status is "up" if the storage is reachable and migrated; otherwise, "down".
+	LatencyMs        float64 `json:"latencyMs"`        // LatencyMs is the round trip time of the connection check in milliseconds.
+	MigrationVersion uint    `json:"migrationVersion"` // MigrationVersion is the version of the last applied schema migration.
+	MigrationDirty   bool    `json:"migrationDirty"`   // MigrationDirty reports whether the last migration failed midway.
+	Error            string  `json:"error,omitempty"`  // Error summarizes why the storage is down, without internal details.
+}
+
+const (
+	healthStatusUp   = "up"
+	healthStatusDown = "down"
+)
+
+// NewHealthHandler creates a new HealthHandler checking the specified ProductStore.
+func NewHealthHandler(store storage.ProductStore) *HealthHandler {
+	return &HealthHandler{store: store}
+}
+
+// RegisterRoutes registers the health routes to the provided router.
+func (handler *HealthHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /health/live", handler.handleLive)
+	router.HandleFunc("GET /health/ready", handler.handleReady)
+}
+
+// handleLive reports that the process is running and able to serve HTTP requests, without checking its dependencies.
+func (handler *HealthHandler) handleLive(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, &HealthStatus{Status: healthStatusUp})
+}
+
+// markShuttingDown makes the readiness checks fail from now on, so traffic is routed away before the server stops.
+func (handler *HealthHandler) markShuttingDown() {
+	handler.shuttingDown.Store(true)
+}
+
+// handleReady reports whether the service can serve product requests, checking the storage connection and schema.
+// It responds with 503 Service Unavailable if the server is shutting down, the storage is unreachable or its last migration is dirty.
+func (handler *HealthHandler) handleReady(w http.ResponseWriter, r *http.Request) {
+	if handler.shuttingDown.Load() {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, &HealthStatus{Status: healthStatusDown})
+		return
+	}
+
+	storageHealth := handler.checkStorage(r)
+
+	if storageHealth.Status != healthStatusUp {
+		utils.WriteJSON(w, http.StatusServiceUnavailable, &HealthStatus{Status: healthStatusDown, Storage: storageHealth})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, &HealthStatus{Status: healthStatusUp, Storage: storageHealth})
+}
+
+// checkStorage measures the latency of the storage connection check and reads the schema migration state.
+// Failures are logged with their details, while the returned report only summarizes them.
+func (handler *HealthHandler) checkStorage(r *http.Request) *StorageHealth {
+	storageHealth := &StorageHealth{Status: healthStatusDown}
+
+	start := time.Now()
+	err := handler.store.VerifyStoreConnection(r.Context())
+	storageHealth.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+
+	if err != nil {
+		log.Printf("Readiness check: %v", err)
+		storageHealth.Error = "storage connection failed"
+		return storageHealth
+	}
+
+	storageHealth.MigrationVersion, storageHealth.MigrationDirty, err = handler.store.MigrationVersion(r.Context())
+	if err != nil {
+		log.Printf("Readiness check: %v", err)
+		storageHealth.Error = "migration version unavailable"
+		return storageHealth
+	}
+
+	if storageHealth.MigrationDirty {
+		storageHealth.Error = "last migration is dirty"
+		return storageHealth
+	}
+
+	storageHealth.Status = healthStatusUp
+	return storageHealth
+}
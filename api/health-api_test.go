@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"ntsiris/product-microservice/internal/mocks"
+	"ntsiris/product-microservice/internal/storage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestHealthHandler() (*http.ServeMux, *mocks.MockProductStore) {
+	mockStore := mocks.NewMockProductStore()
+	router := http.NewServeMux()
+	NewHealthHandler(mockStore).RegisterRoutes(router)
+
+	return router, mockStore
+}
+
+func TestHandleLive(t *testing.T) {
+	router, mockStore := setupTestHealthHandler()
+
+	t.Run("reports up even when the storage is unavailable", func(t *testing.T) {
+		mockStore.Err = fmt.Errorf("connection refused: %w", storage.ErrUnavailable)
+		defer func() { mockStore.Err = nil }()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+
+		var status HealthStatus
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+		assert.Equal(t, healthStatusUp, status.Status)
+		assert.Nil(t, status.Storage)
+	})
+}
+
+func TestHandleReady(t *testing.T) {
+	router, mockStore := setupTestHealthHandler()
+
+	t.Run("reports up with the storage details", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		var status HealthStatus
+		assert.Equal(t, http.StatusOK, rr.Code)
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+		assert.Equal(t, healthStatusUp, status.Status)
+		require.NotNil(t, status.Storage)
+		assert.Equal(t, healthStatusUp, status.Storage.Status)
+		assert.GreaterOrEqual(t, status.Storage.LatencyMs, 0.0)
+		assert.Empty(t, status.Storage.Error)
+	})
+
+	t.Run("reports down without leaking the storage error", func(t *testing.T) {
+		mockStore.Err = fmt.Errorf("dial tcp 10.0.0.5:3306: %w", storage.ErrUnavailable)
+		defer func() { mockStore.Err = nil }()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		var status HealthStatus
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+		assert.Equal(t, healthStatusDown, status.Status)
+		require.NotNil(t, status.Storage)
+		assert.Equal(t, healthStatusDown, status.Storage.Status)
+		assert.Equal(t, "storage connection failed", status.Storage.Error)
+		assert.NotContains(t, rr.Body.String(), "10.0.0.5")
+	})
+
+	t.Run("reports down while shutting down", func(t *testing.T) {
+		handler := NewHealthHandler(mockStore)
+		handler.markShuttingDown()
+
+		rr := httptest.NewRecorder()
+		handler.handleReady(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		var status HealthStatus
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+		assert.Equal(t, healthStatusDown, status.Status)
+		assert.Nil(t, status.Storage)
+	})
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"ntsiris/product-microservice/internal/types"
+	"ntsiris/product-microservice/internal/utils"
+	"regexp"
+	"runtime/debug"
+	"time"
+)
+
+// RequestIDHeader is the header carrying the ID of a request, accepted from clients and echoed in every response.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key under which the ID of the request is stored.
+type requestIDKey struct{}
+
+// validRequestID restricts the request IDs accepted from clients, so they can be logged safely.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// withRequestID tags every request with an ID, reusing a valid X-Request-ID header sent by the client or generating
+// a new one. The ID is stored in the request context, for logging, and echoed in the X-Request-ID response header.
+//
+// Parameters:
+// - next: The handler serving the requests.
+//
+// Returns:
+// - An http.Handler tagging the request before delegating to next.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+	})
+}
+
+// newRequestID generates a random 128-bit request ID, hex encoded.
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// requestIDFromContext returns the ID of the request the context belongs to, or an empty string if it has none.
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// withRecovery recovers from panics raised while serving a request, logging the panic with its stack trace and
+// request ID and responding with an Internal Server Error problem, so the client is not left without a response.
+// If the handler already started its response, the problem cannot be sent anymore, so the response is aborted
+// with http.ErrAbortHandler instead. The http.ErrAbortHandler panic, used to abort responses deliberately, is propagated.
+//
+// Parameters:
+// - next: The handler serving the requests.
+//
+// Returns:
+// - An http.Handler recovering the panics of next.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracker := &headerTracker{ResponseWriter: w}
+
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			log.Printf("[%s] panic serving %s %s: %v\n%s", requestIDFromContext(r.Context()), r.Method, r.URL.Path, recovered, debug.Stack())
+
+			if tracker.wroteHeader {
+				panic(http.ErrAbortHandler)
+			}
+
+			apiErr := types.NewAPIError(http.StatusInternalServerError, "Internal server error", r.URL.Path, nil)
+			apiErr.RequestID = requestIDFromContext(r.Context())
+			utils.WriteProblem(w, apiErr.Status, apiErr)
+		}()
+
+		next.ServeHTTP(tracker, r)
+	})
+}
+
+// headerTracker wraps a ResponseWriter, recording whether the response headers were written.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader records that the headers were written before writing them.
+func (tracker *headerTracker) WriteHeader(statusCode int) {
+	tracker.wroteHeader = true
+	tracker.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records that the headers were written, as writing the body writes them implicitly, before writing the data.
+func (tracker *headerTracker) Write(data []byte) (int, error) {
+	tracker.wroteHeader = true
+	return tracker.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the wrapped ResponseWriter, so http.ResponseController reaches its optional interfaces.
+func (tracker *headerTracker) Unwrap() http.ResponseWriter {
+	return tracker.ResponseWriter
+}
+
+// withTimeout bounds the context of every request by the given timeout, so storage operations
+// outliving it are cancelled. A non-positive timeout leaves the request context unbounded.
+//
+// Parameters:
+// - next: The handler serving the requests.
+// - timeout: The maximum duration of a request.
+//
+// Returns:
+// - An http.Handler applying the deadline before delegating to next.
+func withTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
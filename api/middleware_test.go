@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var contextID string
+	handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID = requestIDFromContext(r.Context())
+	}))
+
+	t.Run("reuses a valid client request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, "client-id.42")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, "client-id.42", rec.Header().Get(RequestIDHeader))
+		assert.Equal(t, "client-id.42", contextID)
+	})
+
+	t.Run("generates an ID for missing or invalid client request IDs", func(t *testing.T) {
+		for _, clientID := range []string{"", "bad id\n", string(bytes.Repeat([]byte("a"), 129))} {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(RequestIDHeader, clientID)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Regexp(t, `^[0-9a-f]{32}$`, rec.Header().Get(RequestIDHeader))
+			assert.Equal(t, rec.Header().Get(RequestIDHeader), contextID)
+		}
+	})
+}
+
+func TestWithRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	t.Run("responds with a problem and logs the stack", func(t *testing.T) {
+		handler := withRequestID(withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("Error 1054: Unknown column 'secret' in 'field list'")
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/product/1", nil)
+		req.Header.Set(RequestIDHeader, "panicking-request")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `"panicking-request"`, string(decodeField(t, rec, "requestId")))
+		assert.NotContains(t, rec.Body.String(), "secret")
+		assert.Contains(t, logs.String(), "[panicking-request] panic serving GET /product/1")
+		assert.Contains(t, logs.String(), "runtime/debug.Stack")
+	})
+
+	t.Run("aborts responses already started", func(t *testing.T) {
+		handler := withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"items": [`))
+			panic("encoding failed")
+		}))
+
+		rec := httptest.NewRecorder()
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/product", nil))
+		})
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `{"items": [`, rec.Body.String())
+		assert.Contains(t, logs.String(), "panic serving GET /product: encoding failed")
+	})
+
+	t.Run("propagates aborted handlers", func(t *testing.T) {
+		handler := withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		})
+	})
+}
+
+func TestMakeHTTPHandleFunc(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	t.Run("translates arbitrary errors without leaking them", func(t *testing.T) {
+		handlerFunc := makeHTTPHandleFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return errors.New("Error 1146: Table 'productDB.secret' doesn't exist")
+		})
+
+		rec := httptest.NewRecorder()
+		handlerFunc(rec, httptest.NewRequest(http.MethodGet, "/product", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		assert.NotContains(t, rec.Body.String(), "secret")
+		assert.Contains(t, logs.String(), "productDB.secret")
+	})
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("bounds the request context by the timeout", func(t *testing.T) {
+		var deadline time.Time
+		var hasDeadline bool
+		handler := withTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deadline, hasDeadline = r.Context().Deadline()
+		}), time.Minute)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		require.True(t, hasDeadline)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	})
+
+	t.Run("leaves the request context unbounded without a timeout", func(t *testing.T) {
+		var hasDeadline bool
+		handler := withTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}), 0)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.False(t, hasDeadline)
+	})
+}
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"ntsiris/product-microservice/internal/service"
 	"ntsiris/product-microservice/internal/storage"
 	"ntsiris/product-microservice/internal/types"
 	"ntsiris/product-microservice/internal/utils"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -18,12 +23,27 @@ type ProductHandler struct {
 
 type apiFunc func(http.ResponseWriter, *http.Request) error
 
-// makeHTTPHandleFunc wraps an apiFunc and handles any errors, sending JSON responses with error details.
+// makeHTTPHandleFunc wraps an apiFunc and handles any errors, sending RFC 7807 problem responses with error details.
+// Errors other than APIError are translated into Internal Server Error problems, so their text never reaches clients.
+// Server errors are logged with their cause and the request ID.
 func makeHTTPHandleFunc(f apiFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if err := f(w, r); err != nil {
-			utils.WriteJSON(w, err.(*types.APIError).Code, err)
+		err := f(w, r)
+		if err == nil {
+			return
 		}
+
+		var apiErr *types.APIError
+		if !errors.As(err, &apiErr) {
+			apiErr = types.NewAPIError(http.StatusInternalServerError, "Internal server error", r.URL.Path, err)
+		}
+		apiErr.RequestID = requestIDFromContext(r.Context())
+
+		if apiErr.Status >= http.StatusInternalServerError {
+			log.Printf("[%s] %s %s: %v", apiErr.RequestID, r.Method, r.URL.Path, apiErr)
+		}
+
+		utils.WriteProblem(w, apiErr.Status, apiErr)
 	}
 }
 
@@ -38,9 +58,37 @@ func (handler *ProductHandler) RegisterRoutes(router *http.ServeMux) {
 
 	router.HandleFunc("GET /product/{id}", makeHTTPHandleFunc(handler.handleRetrieve))
 	router.HandleFunc("GET /product", makeHTTPHandleFunc(handler.handleRetrieveAll))
+	router.HandleFunc("GET /product/search", makeHTTPHandleFunc(handler.handleSearch))
+	router.HandleFunc("GET /product/sku/{sku}", makeHTTPHandleFunc(handler.handleRetrieveBySKU))
+
+	// The GET sub-resources of products overlap the lookup by SKU (e.g., /product/sku/prices), which ServeMux rejects
+	// between routes of the same router. They are served by a router of their own, mounted on the /product/{id}/
+	// subtree, that the more specific lookup by SKU takes precedence over.
+	subresources := http.NewServeMux()
+	subresources.HandleFunc("GET /product/{id}/prices", makeHTTPHandleFunc(handler.handleRetrievePrices))
+	subresources.HandleFunc("GET /product/{id}/categories", makeHTTPHandleFunc(handler.handleRetrieveCategories))
+	subresources.HandleFunc("GET /product/{id}/variants", makeHTTPHandleFunc(handler.handleRetrieveVariants))
+	router.Handle("GET /product/{id}/", subresources)
 
 	router.HandleFunc("PUT /product/update/", makeHTTPHandleFunc(handler.handleUpdate))
 
+	router.HandleFunc("POST /product/{id}/stock/increment", makeHTTPHandleFunc(handler.handleStockIncrement))
+	router.HandleFunc("POST /product/{id}/stock/decrement", makeHTTPHandleFunc(handler.handleStockDecrement))
+
+	router.HandleFunc("POST /product/{id}/prices/scheduled", makeHTTPHandleFunc(handler.handleSchedulePrice))
+	router.HandleFunc("DELETE /product/{id}/prices/scheduled/{scheduledId}", makeHTTPHandleFunc(handler.handleCancelScheduledPrice))
+
+	// Replacing the categories is a POST, as a PUT would overlap the PUT /product/update/ subtree.
+	router.HandleFunc("POST /product/{id}/categories", makeHTTPHandleFunc(handler.handleSetCategories))
+
+	router.HandleFunc("POST /product/{id}/variants", makeHTTPHandleFunc(handler.handleCreateVariant))
+	router.HandleFunc("GET /product/{id}/variants/{variantId}", makeHTTPHandleFunc(handler.handleRetrieveVariant))
+	// Replacing the details of a variant is a POST, as a PUT would overlap the PUT /product/update/ subtree.
+	router.HandleFunc("POST /product/{id}/variants/{variantId}", makeHTTPHandleFunc(handler.handleUpdateVariant))
+	router.HandleFunc("DELETE /product/{id}/variants/{variantId}", makeHTTPHandleFunc(handler.handleDeleteVariant))
+	router.HandleFunc("POST /product/{id}/variants/{variantId}/stock/increment", makeHTTPHandleFunc(handler.handleVariantStockIncrement))
+	router.HandleFunc("POST /product/{id}/variants/{variantId}/stock/decrement", makeHTTPHandleFunc(handler.handleVariantStockDecrement))
+
 	router.HandleFunc("DELETE /product/delete/{id}", makeHTTPHandleFunc(handler.handleDelete))
 }
 
@@ -57,20 +105,18 @@ func (handler *ProductHandler) handleCreate(w http.ResponseWriter, r *http.Reque
 	}
 
 	product := service.NewProduct(productPayload)
-	err := handler.store.Create(&product)
+	err := handler.store.Create(r.Context(), &product)
 	if err != nil {
-		return &types.APIError{
-			Code:          http.StatusInternalServerError,
-			Message:       "Product not created",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
-		}
+		return storeError(r, "Product not created", err)
 	}
 
+	setETag(w, product)
 	return utils.WriteJSON(w, http.StatusCreated, product)
 }
 
 // handleRetrieve retrieves a single product by its ID and returns it in JSON format.
+// A currency query parameter expresses the prices in that currency, converted with the stored exchange rate,
+// which is returned along with the product.
 func (handler *ProductHandler) handleRetrieve(w http.ResponseWriter, r *http.Request) error {
 	requestedID, err := parseIntPathValue(r, "id")
 	if err != nil {
@@ -82,60 +128,117 @@ func (handler *ProductHandler) handleRetrieve(w http.ResponseWriter, r *http.Req
 		return err
 	}
 
-	return utils.WriteJSON(w, http.StatusOK, requestedProduct)
+	return handler.writeProduct(w, r, requestedProduct)
 }
 
-// handleRetrieveAll retrieves all products, with optional pagination, and returns them in JSON format.
-func (handler *ProductHandler) handleRetrieveAll(w http.ResponseWriter, r *http.Request) error {
-	pageParam := r.URL.Query().Get("page")
-	limitParam := r.URL.Query().Get("limit")
-
-	page := 1
-	limit := 10
-
-	var err error
-	if pageParam != "" {
-		page, err = strconv.Atoi(pageParam)
-		if err != nil || page < 1 {
-			return &types.APIError{
-				Code:          http.StatusBadRequest,
-				Message:       "Invalid page number",
-				Operation:     types.FormatOperation(r.Method, r.URL.Path),
-				EmbeddedError: err.Error(),
-			}
+// handleRetrieveBySKU retrieves a single product by its SKU, the identifier used by external systems,
+// accepting the same currency query parameter as handleRetrieve.
+func (handler *ProductHandler) handleRetrieveBySKU(w http.ResponseWriter, r *http.Request) error {
+	requestedProduct, err := handler.store.RetrieveBySKU(r.Context(), r.PathValue("sku"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return storeError(r, "Product not found", err)
 		}
+
+		return storeError(r, "Error in product retrieval", err)
 	}
 
-	if limitParam != "" {
-		limit, err = strconv.Atoi(limitParam)
-		if err != nil || limit < 1 {
-			return &types.APIError{
-				Code:          http.StatusBadRequest,
-				Message:       "Invalid limit number",
-				Operation:     types.FormatOperation(r.Method, r.URL.Path),
-				EmbeddedError: err.Error(),
-			}
-		}
+	return handler.writeProduct(w, r, requestedProduct)
+}
+
+// writeProduct writes a retrieved product along with its ETag, its prices expressed in the currency of the
+// currency query parameter if present.
+func (handler *ProductHandler) writeProduct(w http.ResponseWriter, r *http.Request, product *service.Product) error {
+	setETag(w, product)
+	if !r.URL.Query().Has("currency") {
+		return utils.WriteJSON(w, http.StatusOK, product)
+	}
+
+	pricedProduct, err := handler.priceIn(r, product)
+	if err != nil {
+		return err
 	}
 
-	products, err := handler.store.RetrieveAll(page, limit)
+	return utils.WriteJSON(w, http.StatusOK, pricedProduct)
+}
+
+// handleRetrieveAll retrieves all products, with optional pagination, and returns them in a ProductList envelope.
+// Offset pagination is selected by the page and limit query parameters, while keyset pagination is selected by
+// the after query parameter, empty for the first page, whose value is the next_cursor of the previous page.
+// The total number of products is also sent in the X-Total-Count header and the neighbouring pages in the Link header.
+// A category query parameter lists the products of that category, and of its descendants if include_descendants is true.
+func (handler *ProductHandler) handleRetrieveAll(w http.ResponseWriter, r *http.Request) error {
+	query, err := parseProductQuery(r)
 	if err != nil {
-		return &types.APIError{
-			Code:          http.StatusInternalServerError,
-			Message:       "Error in product retrieval",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
+		return err
+	}
+
+	// The parameter was validated along with the query.
+	if includeDescendants, _ := strconv.ParseBool(r.URL.Query().Get("include_descendants")); includeDescendants {
+		categories, err := handler.store.RetrieveCategories(r.Context())
+		if err != nil {
+			return storeError(r, "Error in category retrieval", err)
 		}
+
+		query.Filter.CategoryIDs = service.DescendantIDs(categories, query.Filter.CategoryIDs[0])
+	}
+
+	total, err := handler.store.Count(r.Context(), query)
+	if err != nil {
+		return storeError(r, "Error in product retrieval", err)
+	}
+
+	productList := newProductList(query, total)
+
+	// Keyset pagination fetches one product past the limit to tell whether a following page exists.
+	keyset := r.URL.Query().Has("after")
+	if keyset {
+		productList.Page = 0
+		query.Limit++
+	}
+
+	products, err := handler.store.RetrieveAll(r.Context(), query)
+	if err != nil {
+		return storeError(r, "Error in product retrieval", err)
+	}
+
+	if keyset && len(products) > productList.Limit {
+		products = products[:productList.Limit]
+		productList.NextCursor = service.CursorAfter(products[len(products)-1], query.OrderBy()).Encode()
+	}
+
+	if len(products) > 0 {
+		productList.Items = products
+	}
+
+	setPaginationHeaders(w, r, productList)
+	return utils.WriteJSON(w, http.StatusOK, productList)
+}
+
+// handleSearch searches the name and description of products for the terms of the q query parameter
+// and returns the page of matching products, selected by the page and limit query parameters, the most relevant first.
+func (handler *ProductHandler) handleSearch(w http.ResponseWriter, r *http.Request) error {
+	query, err := parseSearchQuery(r)
+	if err != nil {
+		return err
+	}
+
+	hits, err := handler.store.Search(r.Context(), query)
+	if err != nil {
+		return storeError(r, "Error in product search", err)
 	}
 
-	if len(products) == 0 {
-		products = []*service.Product{}
+	searchResults := &SearchResults{Query: query.Text, Items: []*service.SearchHit{}, Page: query.Page, Limit: query.Limit}
+	if len(hits) > 0 {
+		searchResults.Items = hits
 	}
 
-	return utils.WriteJSON(w, http.StatusOK, products)
+	return utils.WriteJSON(w, http.StatusOK, searchResults)
 }
 
 // handleUpdate handles updating an existing product's details based on the payload.
+// An If-Match header makes the update conditional on the product's current ETag.
+// The attributes are checked by the store against the attribute schemas of the product's categories.
 func (handler *ProductHandler) handleUpdate(w http.ResponseWriter, r *http.Request) error {
 	updatePayload := service.NewDefaultUpdatePayload()
 	if err := parsePayload(r, updatePayload); err != nil {
@@ -151,22 +254,353 @@ func (handler *ProductHandler) handleUpdate(w http.ResponseWriter, r *http.Reque
 		return err
 	}
 
+	expectedVersion, err := checkIfMatch(r, product)
+	if err != nil {
+		return err
+	}
+
 	service.UpdateProduct(product, updatePayload)
 
-	err = handler.store.Update(&product)
+	// Without If-Match the version is zero and the store applies the update unconditionally.
+	product.Version = expectedVersion
+	err = handler.store.Update(r.Context(), &product)
 	if err != nil {
-		return &types.APIError{
-			Code:          http.StatusInternalServerError,
-			Message:       "Product not updated",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
-		}
+		return productWriteError(r, "Product not updated", err)
+	}
+
+	setETag(w, product)
+	return utils.WriteJSON(w, http.StatusOK, product)
+}
+
+// handleStockIncrement handles adding units to a product's stock.
+func (handler *ProductHandler) handleStockIncrement(w http.ResponseWriter, r *http.Request) error {
+	return handler.adjustStock(w, r, 1)
+}
+
+// handleStockDecrement handles removing units from a product's stock, rejecting removals exceeding the available stock.
+func (handler *ProductHandler) handleStockDecrement(w http.ResponseWriter, r *http.Request) error {
+	return handler.adjustStock(w, r, -1)
+}
+
+// adjustStock parses and validates a stock adjustment and sends the signed amount straight to the store,
+// so the change is applied atomically regardless of concurrent adjustments.
+func (handler *ProductHandler) adjustStock(w http.ResponseWriter, r *http.Request, sign int) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	adjustmentPayload := new(service.StockAdjustmentPayload)
+	if err := parsePayload(r, adjustmentPayload); err != nil {
+		return err
 	}
 
+	if err := validateStruct(r, adjustmentPayload); err != nil {
+		return err
+	}
+
+	product, err := handler.store.AdjustStock(r.Context(), service.ProductID(requestedID), sign*adjustmentPayload.Amount)
+	if err != nil {
+		return storeError(r, "Stock not adjusted", err)
+	}
+
+	setETag(w, product)
 	return utils.WriteJSON(w, http.StatusOK, product)
 }
 
+// handleRetrievePrices retrieves a product's current price, its recorded price changes and its pending ones.
+func (handler *ProductHandler) handleRetrievePrices(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	product, err := handler.retrieveProduct(r, service.ProductID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	priceHistory := newPriceHistory(product)
+
+	changes, err := handler.store.RetrievePriceHistory(r.Context(), product.ID)
+	if err != nil {
+		return storeError(r, "Error in price history retrieval", err)
+	}
+	if len(changes) > 0 {
+		priceHistory.Changes = changes
+	}
+
+	scheduledPrices, err := handler.store.RetrieveScheduledPrices(r.Context(), product.ID)
+	if err != nil {
+		return storeError(r, "Error in price history retrieval", err)
+	}
+	if len(scheduledPrices) > 0 {
+		priceHistory.Scheduled = scheduledPrices
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, priceHistory)
+}
+
+// handleSchedulePrice handles scheduling a future price change of a product, applied by the price scheduler once effective.
+func (handler *ProductHandler) handleSchedulePrice(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	schedulePayload := new(service.ScheduledPricePayload)
+	if err := parsePayload(r, schedulePayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, schedulePayload); err != nil {
+		return err
+	}
+
+	product, err := handler.retrieveProduct(r, service.ProductID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	scheduledPrice := service.NewScheduledPrice(product, schedulePayload)
+	if err := handler.store.SchedulePrice(r.Context(), scheduledPrice); err != nil {
+		return storeError(r, "Price not scheduled", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, scheduledPrice)
+}
+
+// handleCancelScheduledPrice handles canceling a pending price change of a product.
+func (handler *ProductHandler) handleCancelScheduledPrice(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	scheduledID, err := parseIntPathValue(r, "scheduledId")
+	if err != nil {
+		return err
+	}
+
+	err = handler.store.CancelScheduledPrice(r.Context(), service.ProductID(requestedID), service.ScheduledPriceID(scheduledID))
+	if err != nil {
+		return storeError(r, "Scheduled price not canceled", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// handleRetrieveCategories retrieves the categories a product is linked to, ordered by name.
+func (handler *ProductHandler) handleRetrieveCategories(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	categories, err := handler.store.RetrieveProductCategories(r.Context(), service.ProductID(requestedID))
+	if err != nil {
+		return storeError(r, "Error in product category retrieval", err)
+	}
+
+	if categories == nil {
+		categories = []*service.Category{}
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, categories)
+}
+
+// handleSetCategories replaces the categories a product is linked to, returning an Unprocessable Entity error
+// if any of the categories does not exist or the product's attributes do not conform to their attribute schemas.
+func (handler *ProductHandler) handleSetCategories(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	categoriesPayload := new(service.ProductCategoriesPayload)
+	if err := parsePayload(r, categoriesPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, categoriesPayload); err != nil {
+		return err
+	}
+
+	product, err := handler.retrieveProduct(r, service.ProductID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	for _, categoryID := range categoriesPayload.CategoryIDs {
+		_, err := handler.store.RetrieveCategory(r.Context(), categoryID)
+		if errors.Is(err, storage.ErrNotFound) {
+			detail := fmt.Sprintf("Category with id %d not found", categoryID)
+			return types.NewAPIError(http.StatusUnprocessableEntity, detail, r.URL.Path, err)
+		}
+		if err != nil {
+			return storeError(r, "Error in category retrieval", err)
+		}
+	}
+
+	if err := handler.store.SetProductCategories(r.Context(), product.ID, categoriesPayload.CategoryIDs); err != nil {
+		return productWriteError(r, "Product categories not updated", err)
+	}
+
+	return handler.handleRetrieveCategories(w, r)
+}
+
+// handleRetrieveVariants retrieves the variants of a product, ordered by ID.
+func (handler *ProductHandler) handleRetrieveVariants(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	variants, err := handler.store.RetrieveVariants(r.Context(), service.ProductID(requestedID))
+	if err != nil {
+		return storeError(r, "Error in variant retrieval", err)
+	}
+
+	if variants == nil {
+		variants = []*service.Variant{}
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, variants)
+}
+
+// handleCreateVariant handles the creation of a new variant of a product.
+// A SKU already used by another variant is rejected with a Conflict error.
+func (handler *ProductHandler) handleCreateVariant(w http.ResponseWriter, r *http.Request) error {
+	requestedID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return err
+	}
+
+	variantPayload := new(service.VariantCreationPayload)
+	if err := parsePayload(r, variantPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, variantPayload); err != nil {
+		return err
+	}
+
+	product, err := handler.retrieveProduct(r, service.ProductID(requestedID))
+	if err != nil {
+		return err
+	}
+
+	variant := service.NewVariant(product, variantPayload)
+	if err := handler.store.CreateVariant(r.Context(), variant); err != nil {
+		return storeError(r, "Variant not created", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusCreated, variant)
+}
+
+// handleRetrieveVariant retrieves a single variant of a product by its ID.
+func (handler *ProductHandler) handleRetrieveVariant(w http.ResponseWriter, r *http.Request) error {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		return err
+	}
+
+	variant, err := handler.retrieveVariant(r, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, variant)
+}
+
+// handleUpdateVariant handles replacing the SKU, size, color and price of a variant, leaving its stock unchanged.
+// A SKU already used by another variant is rejected with a Conflict error.
+func (handler *ProductHandler) handleUpdateVariant(w http.ResponseWriter, r *http.Request) error {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		return err
+	}
+
+	variantPayload := new(service.VariantUpdatePayload)
+	if err := parsePayload(r, variantPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, variantPayload); err != nil {
+		return err
+	}
+
+	product, err := handler.retrieveProduct(r, productID)
+	if err != nil {
+		return err
+	}
+
+	variant, err := handler.retrieveVariant(r, productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	service.UpdateVariant(product, variant, variantPayload)
+	if err := handler.store.UpdateVariant(r.Context(), variant); err != nil {
+		return storeError(r, "Variant not updated", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, variant)
+}
+
+// handleDeleteVariant handles the deletion of a variant of a product.
+func (handler *ProductHandler) handleDeleteVariant(w http.ResponseWriter, r *http.Request) error {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		return err
+	}
+
+	if err := handler.store.DeleteVariant(r.Context(), productID, variantID); err != nil {
+		return storeError(r, "Variant not deleted", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// handleVariantStockIncrement handles adding units to a variant's stock.
+func (handler *ProductHandler) handleVariantStockIncrement(w http.ResponseWriter, r *http.Request) error {
+	return handler.adjustVariantStock(w, r, 1)
+}
+
+// handleVariantStockDecrement handles removing units from a variant's stock, rejecting removals exceeding the available stock.
+func (handler *ProductHandler) handleVariantStockDecrement(w http.ResponseWriter, r *http.Request) error {
+	return handler.adjustVariantStock(w, r, -1)
+}
+
+// adjustVariantStock parses and validates a stock adjustment and sends the signed amount straight to the store,
+// so the change is applied atomically regardless of concurrent adjustments, like adjustStock does for products.
+func (handler *ProductHandler) adjustVariantStock(w http.ResponseWriter, r *http.Request, sign int) error {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		return err
+	}
+
+	adjustmentPayload := new(service.StockAdjustmentPayload)
+	if err := parsePayload(r, adjustmentPayload); err != nil {
+		return err
+	}
+
+	if err := validateStruct(r, adjustmentPayload); err != nil {
+		return err
+	}
+
+	variant, err := handler.store.AdjustVariantStock(r.Context(), productID, variantID, sign*adjustmentPayload.Amount)
+	if err != nil {
+		return storeError(r, "Stock not adjusted", err)
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, variant)
+}
+
 // handleDelete handles the deletion of a product specified by its ID.
+// An If-Match header makes the deletion conditional on the product's current ETag.
 func (handler *ProductHandler) handleDelete(w http.ResponseWriter, r *http.Request) error {
 
 	requestedID, err := parseIntPathValue(r, "id")
@@ -179,60 +613,199 @@ func (handler *ProductHandler) handleDelete(w http.ResponseWriter, r *http.Reque
 		return err
 	}
 
-	err = handler.store.Delete(requestedProduct)
+	expectedVersion, err := checkIfMatch(r, requestedProduct)
 	if err != nil {
-		return &types.APIError{
-			Code:          http.StatusInternalServerError,
-			Message:       "Product not deleted",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
-		}
+		return err
+	}
+
+	deletedVersion := requestedProduct.Version
+	requestedProduct.Version = expectedVersion
+	err = handler.store.Delete(r.Context(), requestedProduct)
+	if err != nil {
+		return storeError(r, "Product not deleted", err)
 	}
 
+	requestedProduct.Version = deletedVersion
 	return utils.WriteJSON(w, http.StatusOK, requestedProduct)
 }
 
 // retrieveProduct retrieves a product by its ID from the storage layer, returning a Not Found error if the product does not exist.
 func (handler *ProductHandler) retrieveProduct(r *http.Request, productID service.ProductID) (*service.Product, error) {
-	requestedProduct, err := handler.store.Retrieve(service.ProductID(productID))
+	requestedProduct, err := handler.store.Retrieve(r.Context(), service.ProductID(productID))
 	if err != nil {
-		return nil, &types.APIError{
-			Code:          http.StatusNotFound,
-			Message:       "Product not found",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, storeError(r, "Product not found", err)
 		}
+
+		return nil, storeError(r, "Error in product retrieval", err)
 	}
 
 	return requestedProduct, nil
 }
 
+// retrieveVariant retrieves a variant of a product from the storage layer, returning a Not Found error if the variant does not exist.
+func (handler *ProductHandler) retrieveVariant(r *http.Request, productID service.ProductID, variantID service.VariantID) (*service.Variant, error) {
+	variant, err := handler.store.RetrieveVariant(r.Context(), productID, variantID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, storeError(r, "Variant not found", err)
+		}
+
+		return nil, storeError(r, "Error in variant retrieval", err)
+	}
+
+	return variant, nil
+}
+
+// priceIn expresses the product's prices in the currency of the currency query parameter, returning a Bad Request
+// error if the currency is not supported and an Unprocessable Entity error if no exchange rate converts to it.
+func (handler *ProductHandler) priceIn(r *http.Request, product *service.Product) (*service.PricedProduct, error) {
+	currency := service.Currency(strings.ToUpper(r.URL.Query().Get("currency")))
+	if !service.IsSupportedCurrency(currency) {
+		apiErr := types.NewAPIError(http.StatusBadRequest, "Invalid query parameters", r.URL.Path, nil)
+		apiErr.Errors = []types.FieldError{{Field: "currency", Tag: "currency"}}
+		return nil, apiErr
+	}
+
+	var exchangeRate *service.ExchangeRate
+	if baseCurrency := cmp.Or(product.Price.Currency, service.DefaultCurrency); currency != baseCurrency {
+		var err error
+		exchangeRate, err = handler.store.RetrieveExchangeRate(r.Context(), baseCurrency, currency)
+		if errors.Is(err, storage.ErrNotFound) {
+			detail := fmt.Sprintf("No exchange rate from %s to %s", baseCurrency, currency)
+			return nil, types.NewAPIError(http.StatusUnprocessableEntity, detail, r.URL.Path, err)
+		}
+		if err != nil {
+			return nil, storeError(r, "Error in exchange rate retrieval", err)
+		}
+	}
+
+	return service.PriceIn(product, currency, exchangeRate)
+}
+
+// storeError converts an error returned by the storage layer into an APIError, mapping the storage
+// sentinel errors to their HTTP status codes and any other error to Internal Server Error.
+// Rejected stock changes carry the requested and available quantities as error details, and rejected schema changes
+// the product whose attributes do not conform along with the violations.
+func storeError(r *http.Request, message string, err error) *types.APIError {
+	code := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		code = http.StatusNotFound
+	case errors.Is(err, storage.ErrConflict):
+		code = http.StatusConflict
+	case errors.Is(err, storage.ErrInsufficientStock), errors.Is(err, storage.ErrNonconformingAttributes):
+		code = http.StatusUnprocessableEntity
+	case errors.Is(err, storage.ErrVersionMismatch):
+		code = http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrUnavailable):
+		code = http.StatusServiceUnavailable
+	}
+
+	apiErr := types.NewAPIError(code, message, r.URL.Path, err)
+
+	var stockErr *storage.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		apiErr.Details = stockErr
+	}
+
+	var attributesErr *storage.NonconformingAttributesError
+	if errors.As(err, &attributesErr) {
+		apiErr.Details = attributesErr
+	}
+
+	return apiErr
+}
+
+// productWriteError converts an error returned by the storage layer while writing a product into an APIError like
+// storeError, except that attributes rejected by the store are reported as an Unprocessable Entity error listing every
+// attribute that does not conform to the attribute schemas of the product's categories, with the expected type as
+// the parameter of type mismatches.
+func productWriteError(r *http.Request, message string, err error) *types.APIError {
+	var attributesErr *storage.NonconformingAttributesError
+	if !errors.As(err, &attributesErr) {
+		return storeError(r, message, err)
+	}
+
+	apiErr := types.NewAPIError(http.StatusUnprocessableEntity, "Product attributes do not match the attribute schemas of its categories", r.URL.Path, err)
+	for _, attributeErr := range attributesErr.Errors {
+		fieldErr := types.FieldError{Field: "attributes[" + attributeErr.Name + "]", Tag: attributeErr.Tag}
+		if attributeErr.Tag == "type" {
+			fieldErr.Param = attributeErr.Type
+		}
+
+		apiErr.Errors = append(apiErr.Errors, fieldErr)
+	}
+
+	return apiErr
+}
+
+// setETag sets the ETag response header to the product's version.
+func setETag(w http.ResponseWriter, product *service.Product) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, product.Version))
+}
+
+// checkIfMatch evaluates the If-Match request header against the product's current version, returning a
+// Precondition Failed error on mismatch. It returns the version the following write must be conditioned on,
+// which is zero if the header is absent or "*".
+func checkIfMatch(r *http.Request, product *service.Product) (int64, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	// Only strong entity tags can match, as required by RFC 9110.
+	version, err := strconv.ParseInt(strings.Trim(ifMatch, `"`), 10, 64)
+	if err != nil || !strings.HasPrefix(ifMatch, `"`) || version != product.Version {
+		detail := fmt.Sprintf("Product was modified: If-Match %s does not match the current ETag \"%d\"", ifMatch, product.Version)
+		return 0, types.NewAPIError(http.StatusPreconditionFailed, detail, r.URL.Path, nil)
+	}
+
+	return version, nil
+}
+
 // parsePayload parses the JSON payload of an HTTP request into the specified structure.
 func parsePayload(r *http.Request, payload any) error {
 	if err := utils.ParseJSON(r, payload); err != nil {
-		return &types.APIError{
-			Code:          http.StatusBadRequest,
-			Message:       "Request Body parsing failed",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
-		}
+		return types.NewAPIError(http.StatusBadRequest, "Request Body parsing failed", r.URL.Path, err)
 	}
 
 	return nil
 }
 
 // validateStruct validates the provided structure using the registered validators, returning an error if validation fails.
+// The error lists every failing field by its JSON path, along with the failed rule and its parameter.
 func validateStruct(r *http.Request, st any) error {
-	if err := utils.Validate.Struct(st); err != nil {
-		return &types.APIError{
-			Code:          http.StatusBadRequest,
-			Message:       "Request payload validation failed",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.(validator.ValidationErrors).Error(),
+	err := utils.Validate.Struct(st)
+	if err == nil {
+		return nil
+	}
+
+	apiErr := types.NewAPIError(http.StatusBadRequest, "Request payload validation failed", r.URL.Path, err)
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		for _, fieldErr := range validationErrs {
+			apiErr.Errors = append(apiErr.Errors, types.FieldError{
+				Field: fieldPath(fieldErr),
+				Tag:   fieldErr.Tag(),
+				Param: fieldErr.Param(),
+			})
 		}
 	}
 
-	return nil
+	return apiErr
+}
+
+// fieldPath returns the JSON path of a failing field, dropping the name of the validated struct from its namespace.
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+
+	return path
 }
 
 // parseIntPathValue parses an integer path parameter from the URL, returning a formatted error if parsing fails.
@@ -241,13 +814,23 @@ func parseIntPathValue(r *http.Request, name string) (int64, error) {
 
 	value, err := strconv.ParseInt(requestedValueStr, 10, 64)
 	if err != nil {
-		return -1, &types.APIError{
-			Code:          http.StatusBadRequest,
-			Message:       "Invalid format of ID",
-			Operation:     types.FormatOperation(r.Method, r.URL.Path),
-			EmbeddedError: err.Error(),
-		}
+		return -1, types.NewAPIError(http.StatusBadRequest, "Invalid format of ID", r.URL.Path, err)
 	}
 
 	return value, nil
 }
+
+// parseVariantPath parses the product and variant IDs of a variant's URL, returning a formatted error if parsing fails.
+func parseVariantPath(r *http.Request) (service.ProductID, service.VariantID, error) {
+	productID, err := parseIntPathValue(r, "id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	variantID, err := parseIntPathValue(r, "variantId")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return service.ProductID(productID), service.VariantID(variantID), nil
+}
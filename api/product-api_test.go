@@ -2,14 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"ntsiris/product-microservice/internal/config"
 	"ntsiris/product-microservice/internal/mocks"
 	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/types"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func setupTestProductHandler() (*ProductHandler, *mocks.MockProductStore) {
@@ -22,7 +31,7 @@ func TestHandleCreate(t *testing.T) {
 	handler, mockStore := setupTestProductHandler()
 
 	t.Run("successfully creates a product", func(t *testing.T) {
-		payload := `{"name": "Test Product", "price": 100, "quantity": 10, "discount": 5.0, "description": "Test description"}`
+		payload := `{"sku": "SKU-1", "name": "Test Product", "price": 100, "quantity": 10, "discount": 5.0, "description": "Test description"}`
 		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
 		req.Header.Set("Content-Type", "application/json")
 		rec := httptest.NewRecorder()
@@ -32,13 +41,40 @@ func TestHandleCreate(t *testing.T) {
 
 		assert.Equal(t, http.StatusCreated, rec.Code)
 		assert.Equal(t, int64(1), int64(mockStore.Products[1].ID))
+		assert.Equal(t, "SKU-1", mockStore.Products[1].SKU)
 		assert.Equal(t, "Test Product", mockStore.Products[1].Name)
 		assert.Equal(t, "Test description", mockStore.Products[1].Description)
-		assert.Equal(t, float64(100), float64(mockStore.Products[1].Price))
+		assert.Equal(t, service.Money{Amount: 10000, Currency: service.DefaultCurrency}, mockStore.Products[1].Price)
 		assert.Equal(t, 10, mockStore.Products[1].Quantity)
 		assert.Equal(t, float32(5.0), float32(mockStore.Products[1].Discount))
 	})
 
+	t.Run("creates a product with normalized tags", func(t *testing.T) {
+		payload := `{"sku": "SKU-2", "name": "Tagged Product", "price": 100, "quantity": 1, "tags": ["Summer", " sale ", "summer"]}`
+		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `["sale", "summer"]`, string(decodeField(t, rec, "tags")))
+	})
+
+	t.Run("returns 400 for a blank tag", func(t *testing.T) {
+		payload := `{"sku": "SKU-3", "name": "Tagged Product", "price": 100, "quantity": 1, "tags": ["sale", " "]}`
+		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "tags[1]", Tag: "tag"}}, problem.Errors)
+	})
+
 	t.Run("returns 400 for invalid payload", func(t *testing.T) {
 		payload := `{"name": ""}`
 		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
@@ -52,7 +88,7 @@ func TestHandleCreate(t *testing.T) {
 
 	t.Run("returns 500 on store error", func(t *testing.T) {
 		mockStore.Err = errors.New("db error")
-		payload := `{"name": "Test Product", "price": 100, "quantity": 10, "discount": 5.0, "description": "Test description"}`
+		payload := `{"sku": "SKU-4", "name": "Test Product", "price": 100, "quantity": 10, "discount": 5.0, "description": "Test description"}`
 		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
 		rec := httptest.NewRecorder()
 
@@ -63,6 +99,19 @@ func TestHandleCreate(t *testing.T) {
 		mockStore.Err = nil // Reset error for other tests
 	})
 
+	t.Run("returns 409 on store conflict", func(t *testing.T) {
+		mockStore.Err = fmt.Errorf("%w: duplicate entry", storage.ErrConflict)
+		payload := `{"sku": "SKU-5", "name": "Test Product", "price": 100, "quantity": 10, "discount": 5.0, "description": "Test description"}`
+		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		mockStore.Err = nil // Reset error for other tests
+	})
+
 	t.Run("fails with missing required fields", func(t *testing.T) {
 		// Missing fields such as "price" and "quantity"
 		payload := `{"name": "Test Product", "description": "This is a test"}`
@@ -73,10 +122,86 @@ func TestHandleCreate(t *testing.T) {
 		handlerFunc(rec, req)
 
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+		assert.JSONEq(t, `[{"field": "price", "tag": "required"}, {"field": "quantity", "tag": "required"}, {"field": "sku", "tag": "required"}]`, string(decodeField(t, rec, "errors")))
+	})
+
+	t.Run("returns the price as an exact decimal string", func(t *testing.T) {
+		payload := `{"sku": "SKU-6", "name": "Decimal Product", "price": "0.10", "quantity": 3}`
+		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `"0.10"`, string(decodeField(t, rec, "price")))
+	})
+
+	t.Run("fails with a negative price or sub-cent precision", func(t *testing.T) {
+		for _, price := range []string{`-5`, `"19.999"`} {
+			payload := `{"sku": "SKU-7", "name": "Test Product", "price": ` + price + `, "quantity": 10}`
+			req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, price)
+		}
+	})
+
+	t.Run("accepts prices up to the largest storable amount", func(t *testing.T) {
+		for price, expected := range map[string]int{
+			`"99999999.99"`:  http.StatusCreated,
+			`"100000000.00"`: http.StatusBadRequest,
+			`1000000000`:     http.StatusBadRequest,
+		} {
+			payload := `{"sku": "SKU-11", "name": "Expensive Product", "price": ` + price + `, "quantity": 1}`
+			req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, expected, rec.Code, price)
+			if expected == http.StatusBadRequest {
+				assert.JSONEq(t, `[{"field": "price", "tag": "money"}]`, string(decodeField(t, rec, "errors")), price)
+			}
+		}
+	})
+
+	t.Run("returns the discounted final price", func(t *testing.T) {
+		payload := `{"sku": "SKU-8", "name": "Discounted Product", "price": "19.99", "quantity": 3, "discount": 10}`
+		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `"17.99"`, string(decodeField(t, rec, "finalPrice")))
+	})
+
+	t.Run("fails with a discount outside of 0 to 100 percent", func(t *testing.T) {
+		for discount, expected := range map[string]string{
+			`150`:  `[{"field": "discount", "tag": "lte", "param": "100"}]`,
+			`-0.5`: `[{"field": "discount", "tag": "gte", "param": "0"}]`,
+		} {
+			payload := `{"sku": "SKU-9", "name": "Test Product", "price": 10, "quantity": 10, "discount": ` + discount + `}`
+			req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleCreate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, discount)
+			assert.JSONEq(t, expected, string(decodeField(t, rec, "errors")), discount)
+		}
 	})
 
 	t.Run("fails with invalid data type for price", func(t *testing.T) {
-		payload := `{"name": "Test Product", "price": "invalid_price", "quantity": 10, "description": "Test description"}`
+		payload := `{"sku": "SKU-10", "name": "Test Product", "price": "invalid_price", "quantity": 10, "description": "Test description"}`
 		req := httptest.NewRequest(http.MethodPost, "/product/create", bytes.NewBufferString(payload))
 		rec := httptest.NewRecorder()
 
@@ -91,7 +216,7 @@ func TestHandleRetrieve(t *testing.T) {
 	handler, mockStore := setupTestProductHandler()
 
 	// Add a product to retrieve
-	mockStore.Products[1] = &service.Product{ID: 1, Name: "Test Product"}
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Test Product", Version: 3}
 
 	t.Run("successfully retrieves a product", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/product/1", nil)
@@ -102,6 +227,52 @@ func TestHandleRetrieve(t *testing.T) {
 		handlerFunc(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+	})
+
+	t.Run("converts the prices to the requested currency", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, Name: "Priced Product", Price: service.Money{Amount: 1000, Currency: "EUR"}, Discount: 10}
+		require.NoError(t, mockStore.SaveExchangeRate(context.Background(), &service.ExchangeRate{Base: "EUR", Quote: "USD", Rate: 1085000}))
+
+		req := httptest.NewRequest(http.MethodGet, "/product/2?currency=usd", nil)
+		req.SetPathValue("id", "2")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieve)
+		handlerFunc(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, "10.85", body["price"])
+		assert.Equal(t, "9.76", body["finalPrice"])
+		assert.Equal(t, "USD", body["currency"])
+		assert.Equal(t, "1.085000", body["exchangeRate"].(map[string]any)["rate"])
+	})
+
+	t.Run("returns 400 for an unsupported currency", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/1?currency=XYZ", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieve)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var problem types.APIError
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "currency", Tag: "currency"}}, problem.Errors)
+	})
+
+	t.Run("returns 422 if no exchange rate converts to the currency", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/1?currency=GBP", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieve)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 	})
 
 	t.Run("returns 404 if product not found", func(t *testing.T) {
@@ -114,6 +285,67 @@ func TestHandleRetrieve(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 	})
+
+	t.Run("returns 503 if the store is unavailable", func(t *testing.T) {
+		mockStore.Err = fmt.Errorf("%w: connection refused", storage.ErrUnavailable)
+		req := httptest.NewRequest(http.MethodGet, "/product/1", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieve)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		mockStore.Err = nil // Reset error for other tests
+	})
+}
+
+func TestHandleRetrieveBySKU(t *testing.T) {
+	_, mockStore := setupTestProductHandler()
+	router := NewAPIServer(&config.APIServerConfig{RequestTimeout: time.Second}, mockStore).Handler()
+
+	mockStore.Products[1] = &service.Product{ID: 1, SKU: "ERP-0042", Name: "Test Product", Version: 3}
+
+	retrieve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/product/"+path, nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("retrieves the product with the SKU", func(t *testing.T) {
+		rec := retrieve("sku/ERP-0042")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "1", string(decodeField(t, rec, "id")))
+		assert.Equal(t, `"ERP-0042"`, string(decodeField(t, rec, "sku")))
+	})
+
+	t.Run("returns 404 for an unknown SKU", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, retrieve("sku/ERP-9999").Code)
+	})
+
+	t.Run("retrieves SKUs named like a sub-resource or containing slashes", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, SKU: "variants", Name: "Oddly Named Product"}
+		mockStore.Products[3] = &service.Product{ID: 3, SKU: "ERP/0043", Name: "Slashed Product"}
+
+		rec := retrieve("sku/variants")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", string(decodeField(t, rec, "id")))
+
+		rec = retrieve("sku/" + url.PathEscape("ERP/0043"))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", string(decodeField(t, rec, "id")))
+	})
+
+	t.Run("still routes the sub-resources of a product", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, retrieve("1/prices").Code)
+		assert.Equal(t, http.StatusOK, retrieve("1/categories").Code)
+		assert.Equal(t, http.StatusOK, retrieve("1/variants").Code)
+		assert.Equal(t, http.StatusNotFound, retrieve("1/unknown").Code)
+	})
 }
 
 func TestHandleRetrieveAll(t *testing.T) {
@@ -130,8 +362,29 @@ func TestHandleRetrieveAll(t *testing.T) {
 		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
 		handlerFunc(rec, req)
 
+		var productList ProductList
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+		assert.Len(t, productList.Items, 2)
+		assert.Equal(t, 1, productList.Page)
+		assert.Equal(t, 10, productList.Limit)
+		assert.Equal(t, 2, productList.Total)
+		assert.Equal(t, 1, productList.TotalPages)
+		assert.Equal(t, "2", rec.Header().Get("X-Total-Count"))
+	})
+
+	t.Run("links to the neighbouring pages", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/product?page=2&limit=1&sort=name", nil)
+		req.URL.Path = "/product" // The path as stripped by the API prefix.
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
 		assert.Equal(t, http.StatusOK, rec.Code)
-		// TODO: check the body here for the correct JSON response.
+		assert.Equal(t, `</api/v1/product?limit=1&page=1&sort=name>; rel="first", `+
+			`</api/v1/product?limit=1&page=1&sort=name>; rel="prev", `+
+			`</api/v1/product?limit=1&page=2&sort=name>; rel="last"`, rec.Header().Get("Link"))
 	})
 
 	t.Run("returns empty list if no products found", func(t *testing.T) {
@@ -145,7 +398,8 @@ func TestHandleRetrieveAll(t *testing.T) {
 		handlerFunc(rec, req)
 
 		assert.Equal(t, http.StatusOK, rec.Code)
-		assert.JSONEq(t, `[]`, rec.Body.String())
+		assert.JSONEq(t, `{"items": [], "page": 1, "limit": 10, "total": 0, "totalPages": 0}`, rec.Body.String())
+		assert.Equal(t, `</product?limit=10&page=1>; rel="first", </product?limit=10&page=1>; rel="last"`, rec.Header().Get("Link"))
 	})
 
 	t.Run("returns 400 for invalid pagination parameters", func(t *testing.T) {
@@ -158,6 +412,175 @@ func TestHandleRetrieveAll(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
+	t.Run("accepts limits up to the maximum", func(t *testing.T) {
+		for _, query := range []string{"limit=100", "after=&limit=100"} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code, query)
+			assert.Equal(t, "100", string(decodeField(t, rec, "limit")), query)
+		}
+	})
+
+	t.Run("returns 400 for limits above the maximum", func(t *testing.T) {
+		for _, query := range []string{"limit=101", "after=&limit=1000000"} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, []types.FieldError{{Field: "limit", Tag: "max", Param: "100"}}, problem.Errors, query)
+		}
+	})
+
+	t.Run("pages through products with cursors", func(t *testing.T) {
+		for id := int64(1); id <= 3; id++ {
+			mockStore.Products[id] = &service.Product{ID: service.ProductID(id), Name: fmt.Sprintf("Product %d", id)}
+		}
+
+		var listed []service.ProductID
+		url := "/product?after=&limit=2"
+		for pages := 0; url != ""; pages++ {
+			require.Less(t, pages, 3, "listing did not end")
+
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var productList ProductList
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+
+			for _, product := range productList.Items {
+				listed = append(listed, product.ID)
+			}
+
+			assert.Zero(t, productList.Page)
+			assert.Equal(t, 3, productList.Total)
+
+			url = ""
+			if productList.NextCursor != "" {
+				url = "/product?limit=2&after=" + productList.NextCursor
+				assert.Contains(t, rec.Header().Get("Link"), `</product?after=`+productList.NextCursor+`&limit=2>; rel="next"`)
+			}
+		}
+
+		assert.Equal(t, []service.ProductID{1, 2, 3}, listed)
+	})
+
+	t.Run("returns 400 for invalid cursors", func(t *testing.T) {
+		for _, query := range []string{"after=not-a-cursor", "after=&page=2"} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+	})
+
+	t.Run("filters and sorts products", func(t *testing.T) {
+		mockStore.Products = map[int64]*service.Product{
+			1: {ID: 1, Name: "Blue Widget", Price: service.Money{Amount: 1200}, Quantity: 3},
+			2: {ID: 2, Name: "Red Widget", Price: service.Money{Amount: 800}, Quantity: 0},
+			3: {ID: 3, Name: "Green Widget", Price: service.Money{Amount: 1200}, Quantity: 5},
+			4: {ID: 4, Name: "Gadget", Price: service.Money{Amount: 1000}, Quantity: 1},
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/product?name_contains=widget&min_price=5&max_price=20&in_stock=true&sort=-price,-name", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		var productList ProductList
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+		require.Len(t, productList.Items, 2)
+		assert.Equal(t, service.ProductID(3), productList.Items[0].ID)
+		assert.Equal(t, service.ProductID(1), productList.Items[1].ID)
+		assert.Equal(t, 2, productList.Total)
+	})
+
+	t.Run("filters products by tag", func(t *testing.T) {
+		mockStore.Products = map[int64]*service.Product{
+			1: {ID: 1, Name: "Shirt", Tags: []string{"sale", "summer"}},
+			2: {ID: 2, Name: "Shorts", Tags: []string{"summer"}},
+			3: {ID: 3, Name: "Coat", Tags: []string{"winter"}},
+		}
+
+		for _, test := range []struct {
+			query string
+			ids   []service.ProductID
+		}{
+			{"tag=Summer", []service.ProductID{1, 2}},
+			{"tag=summer&tag=sale", []service.ProductID{1}},
+			{"tag=summer&tag=sale&match=all", []service.ProductID{1}},
+			{"tag=sale&tag=winter&match=any", []service.ProductID{1, 3}},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+test.query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var productList ProductList
+			assert.Equal(t, http.StatusOK, rec.Code, test.query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+			var listed []service.ProductID
+			for _, product := range productList.Items {
+				listed = append(listed, product.ID)
+			}
+			assert.Equal(t, test.ids, listed, test.query)
+		}
+	})
+
+	t.Run("returns 400 listing every invalid query parameter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product?min_price=30&max_price=20&in_stock=maybe&sort=color", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.ElementsMatch(t, []types.FieldError{
+			{Field: "max_price", Tag: "gtefield", Param: "min_price"},
+			{Field: "in_stock", Tag: "boolean"},
+			{Field: "sort", Tag: "oneof", Param: "id name price quantity createdAt lastUpdated"},
+		}, problem.Errors)
+	})
+
+	t.Run("returns 400 for invalid tag parameters", func(t *testing.T) {
+		for query, errs := range map[string][]types.FieldError{
+			"tag=%20":             {{Field: "tag", Tag: "tag"}},
+			"tag=sale&match=some": {{Field: "match", Tag: "oneof", Param: "all any"}},
+			"match=any":           {{Field: "match", Tag: "required_with", Param: "tag"}},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, errs, problem.Errors, query)
+		}
+	})
+
 	t.Run("returns 500 on store error", func(t *testing.T) {
 		mockStore.Err = errors.New("internal store error")
 		req := httptest.NewRequest(http.MethodGet, "/product?page=1&limit=10", nil)
@@ -171,6 +594,82 @@ func TestHandleRetrieveAll(t *testing.T) {
 	})
 }
 
+func TestHandleSearch(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Coffee Grinder", Description: "Grinds beans for espresso"}
+	mockStore.Products[2] = &service.Product{ID: 2, Name: "Espresso Machine", Description: "Brews espresso"}
+	mockStore.Products[3] = &service.Product{ID: 3, Name: "Tea Kettle", Description: "Boils water"}
+
+	t.Run("returns the matching products by relevance", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/search?q=Espresso", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSearch)
+		handlerFunc(rec, req)
+
+		var searchResults SearchResults
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&searchResults))
+		require.Len(t, searchResults.Items, 2)
+		assert.Equal(t, service.ProductID(2), searchResults.Items[0].ID)
+		assert.Equal(t, service.ProductID(1), searchResults.Items[1].ID)
+		assert.Greater(t, searchResults.Items[0].Score, searchResults.Items[1].Score)
+		assert.Equal(t, "Espresso", searchResults.Query)
+		assert.Equal(t, 1, searchResults.Page)
+		assert.Equal(t, service.DefaultPageLimit, searchResults.Limit)
+	})
+
+	t.Run("returns an empty list without a match", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/search?q=bicycle&limit=5", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSearch)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"query": "bicycle", "items": [], "page": 1, "limit": 5}`, rec.Body.String())
+	})
+
+	t.Run("returns 400 for a query without terms", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/search?q=%20-%20&page=0", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSearch)
+		handlerFunc(rec, req)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "q", Tag: "required"}, {Field: "page", Tag: "min", Param: "1"}}, problem.Errors)
+	})
+
+	t.Run("returns 400 for a limit above the maximum", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/product/search?q=bicycle&limit=101", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSearch)
+		handlerFunc(rec, req)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "limit", Tag: "max", Param: "100"}}, problem.Errors)
+	})
+
+	t.Run("returns 500 on store error", func(t *testing.T) {
+		mockStore.Err = errors.New("internal store error")
+		req := httptest.NewRequest(http.MethodGet, "/product/search?q=espresso", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSearch)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		mockStore.Err = nil // Reset error for other tests
+	})
+}
+
 func TestHandleUpdate(t *testing.T) {
 	handler, mockStore := setupTestProductHandler()
 
@@ -190,6 +689,26 @@ func TestHandleUpdate(t *testing.T) {
 		assert.Equal(t, "Updated Product", mockStore.Products[1].Name)
 	})
 
+	t.Run("replaces the tags only if sent", func(t *testing.T) {
+		for _, test := range []struct {
+			payload string
+			tags    []string
+		}{
+			{`{"id": 1, "tags": ["Sale", "new"]}`, []string{"new", "sale"}},
+			{`{"id": 1, "name": "Renamed Product"}`, []string{"new", "sale"}},
+			{`{"id": 1, "tags": []}`, []string{}},
+		} {
+			req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(test.payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code, test.payload)
+			assert.Equal(t, test.tags, mockStore.Products[1].Tags, test.payload)
+		}
+	})
+
 	t.Run("returns 404 if product not found", func(t *testing.T) {
 		payload := `{"id": 999, "name": "Non-existent Product"}`
 		req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
@@ -223,6 +742,90 @@ func TestHandleUpdate(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 
+	t.Run("returns 400 for a negative discount", func(t *testing.T) {
+		mockStore.Products[1].Discount = 10
+
+		payload := `{"id": 1, "discount": -5}`
+		req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+		handlerFunc(rec, req)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "discount", Tag: "eq=-1|gte=0", Param: "0"}}, problem.Errors)
+		assert.Equal(t, float32(10), mockStore.Products[1].Discount)
+	})
+
+	t.Run("returns 400 for a zero or negative price", func(t *testing.T) {
+		mockStore.Products[1].Price = service.Money{Amount: 1500, Currency: service.DefaultCurrency}
+
+		for _, payload := range []string{`{"id": 1, "price": 0}`, `{"id": 1, "price": "-5.00"}`} {
+			req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+			handlerFunc(rec, req)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, payload)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, []types.FieldError{{Field: "price", Tag: "eq=-1|gt=0", Param: "0"}}, problem.Errors, payload)
+			assert.Equal(t, int64(1500), mockStore.Products[1].Price.Amount, payload)
+		}
+	})
+
+	t.Run("updates a product matching If-Match", func(t *testing.T) {
+		mockStore.Products[3] = &service.Product{ID: 3, Name: "Versioned Product", Version: 4}
+
+		payload := `{"id": 3, "name": "Conditionally Updated Product"}`
+		req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
+		req.Header.Set("If-Match", `"4"`)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "Conditionally Updated Product", mockStore.Products[3].Name)
+	})
+
+	t.Run("returns 412 if If-Match is stale", func(t *testing.T) {
+		mockStore.Products[4] = &service.Product{ID: 4, Name: "Versioned Product", Version: 7}
+
+		for _, ifMatch := range []string{`"6"`, `W/"7"`, `invalid`} {
+			payload := `{"id": 4, "name": "Overwritten Product"}`
+			req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
+			req.Header.Set("If-Match", ifMatch)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleUpdate)
+			handlerFunc(rec, req)
+
+			assert.Equal(t, http.StatusPreconditionFailed, rec.Code, ifMatch)
+			assert.Equal(t, "Versioned Product", mockStore.Products[4].Name)
+		}
+	})
+
+	t.Run("returns 422 with quantities if stock would go negative", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, Name: "Scarce Product", Quantity: 2}
+		staleHandler := NewProductHandler(&staleStore{MockProductStore: mockStore, staleQuantity: 5})
+
+		payload := `{"id": 2, "quantity": 0}`
+		req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(staleHandler.handleUpdate)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, `{"productId": 2, "requested": 5, "available": 2}`, string(decodeField(t, rec, "details")))
+		assert.Equal(t, 2, mockStore.Products[2].Quantity)
+	})
+
 	t.Run("partially updates product with valid fields", func(t *testing.T) {
 		payload := `{"id": 1, "name": "Partially Updated Product"}`
 		req := httptest.NewRequest(http.MethodPut, "/product/update", bytes.NewBufferString(payload))
@@ -237,6 +840,517 @@ func TestHandleUpdate(t *testing.T) {
 	})
 }
 
+func TestHandleStockAdjustment(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	// Add a product to adjust
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Test Product", Quantity: 10}
+
+	t.Run("increments the stock", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/product/1/stock/increment", bytes.NewBufferString(`{"amount": 5}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleStockIncrement)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 15, mockStore.Products[1].Quantity)
+	})
+
+	t.Run("decrements the stock", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/product/1/stock/decrement", bytes.NewBufferString(`{"amount": 12}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleStockDecrement)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 3, mockStore.Products[1].Quantity)
+	})
+
+	t.Run("returns 422 if the stock is insufficient", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/product/1/stock/decrement", bytes.NewBufferString(`{"amount": 4}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleStockDecrement)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, `{"productId": 1, "requested": 4, "available": 3}`, string(decodeField(t, rec, "details")))
+		assert.Equal(t, 3, mockStore.Products[1].Quantity)
+	})
+
+	t.Run("returns 400 for a non-positive amount", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/product/1/stock/increment", bytes.NewBufferString(`{"amount": -2}`))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleStockIncrement)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `[{"field": "amount", "tag": "gt", "param": "0"}]`, string(decodeField(t, rec, "errors")))
+	})
+
+	t.Run("returns 404 if product not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/product/999/stock/increment", bytes.NewBufferString(`{"amount": 1}`))
+		req.SetPathValue("id", "999")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleStockIncrement)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+// staleStore simulates a concurrent stock change by returning products with an outdated quantity.
+type staleStore struct {
+	*mocks.MockProductStore
+	staleQuantity int
+}
+
+func (store *staleStore) Retrieve(ctx context.Context, id service.ProductID) (*service.Product, error) {
+	product, err := store.MockProductStore.Retrieve(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	product.Quantity = store.staleQuantity
+	return product, nil
+}
+
+// decodeField decodes the JSON response body and returns the raw value of the named top-level field.
+func decodeField(t *testing.T, rec *httptest.ResponseRecorder, name string) json.RawMessage {
+	var body map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return body[name]
+}
+
+func TestHandlePrices(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	// Add a product whose price changes
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Test Product", Price: service.Money{Amount: 1999, Currency: "EUR"}, Version: 1}
+	effectiveAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	t.Run("schedules a price change", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"price": "24.99", "effectiveAt": %q}`, effectiveAt)
+		req := httptest.NewRequest(http.MethodPost, "/product/1/prices/scheduled", bytes.NewBufferString(payload))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSchedulePrice)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		require.Len(t, mockStore.ScheduledPrices, 1)
+		assert.Equal(t, service.Money{Amount: 2499, Currency: "EUR"}, mockStore.ScheduledPrices[1].Price)
+	})
+
+	t.Run("returns 400 for a past effective time", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"price": "24.99", "effectiveAt": %q}`, time.Now().Add(-time.Hour).UTC().Format(time.RFC3339))
+		req := httptest.NewRequest(http.MethodPost, "/product/1/prices/scheduled", bytes.NewBufferString(payload))
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSchedulePrice)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var problem types.APIError
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "effectiveAt", Tag: "gt"}}, problem.Errors)
+	})
+
+	t.Run("returns 404 when scheduling for an unknown product", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"price": "24.99", "effectiveAt": %q}`, effectiveAt)
+		req := httptest.NewRequest(http.MethodPost, "/product/999/prices/scheduled", bytes.NewBufferString(payload))
+		req.SetPathValue("id", "999")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSchedulePrice)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("lists the price changes and the pending ones", func(t *testing.T) {
+		product := *mockStore.Products[1]
+		product.Price = service.Money{Amount: 2199, Currency: "EUR"}
+		updated := &product
+		require.NoError(t, mockStore.Update(context.Background(), &updated))
+
+		req := httptest.NewRequest(http.MethodGet, "/product/1/prices", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrievePrices)
+		handlerFunc(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body struct {
+			Price     string           `json:"price"`
+			Currency  string           `json:"currency"`
+			Changes   []map[string]any `json:"changes"`
+			Scheduled []map[string]any `json:"scheduled"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, "21.99", body.Price)
+		assert.Equal(t, "EUR", body.Currency)
+		require.Len(t, body.Changes, 1)
+		assert.Equal(t, "19.99", body.Changes[0]["previousPrice"])
+		assert.Equal(t, "21.99", body.Changes[0]["price"])
+		require.Len(t, body.Scheduled, 1)
+		assert.Equal(t, "24.99", body.Scheduled[0]["price"])
+	})
+
+	t.Run("cancels a pending price change", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/product/1/prices/scheduled/1", nil)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("scheduledId", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCancelScheduledPrice)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, mockStore.ScheduledPrices)
+	})
+
+	t.Run("returns 404 when canceling an unknown price change", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/product/1/prices/scheduled/1", nil)
+		req.SetPathValue("id", "1")
+		req.SetPathValue("scheduledId", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleCancelScheduledPrice)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestHandleProductCategories(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	electronics := service.CategoryID(1)
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Phone"}
+	mockStore.Categories[1] = &service.Category{ID: 1, Name: "Electronics"}
+	mockStore.Categories[2] = &service.Category{ID: 2, Name: "Phones", ParentID: &electronics}
+
+	setCategories := func(id, payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/product/"+id+"/categories", bytes.NewBufferString(payload))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleSetCategories)
+		handlerFunc(rec, req)
+		return rec
+	}
+
+	t.Run("links the product to the categories", func(t *testing.T) {
+		rec := setCategories("1", `{"categoryIds": [2, 1]}`)
+
+		var categories []*service.Category
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&categories))
+		require.Len(t, categories, 2)
+		assert.Equal(t, "Electronics", categories[0].Name)
+		assert.Equal(t, "Phones", categories[1].Name)
+	})
+
+	t.Run("returns 400 without category IDs", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, setCategories("1", `{}`).Code)
+	})
+
+	t.Run("returns 422 for an unknown category", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnprocessableEntity, setCategories("1", `{"categoryIds": [1, 99]}`).Code)
+		assert.Equal(t, []service.CategoryID{1, 2}, mockStore.ProductCategories[1])
+	})
+
+	t.Run("returns 404 for an unknown product", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, setCategories("999", `{"categoryIds": [1]}`).Code)
+	})
+
+	t.Run("lists the linked categories", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, setCategories("1", `{"categoryIds": []}`).Code)
+
+		req := httptest.NewRequest(http.MethodGet, "/product/1/categories", nil)
+		req.SetPathValue("id", "1")
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveCategories)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("filters the listing by category", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, Name: "Laptop"}
+		mockStore.Products[3] = &service.Product{ID: 3, Name: "Novel"}
+		mockStore.ProductCategories[1] = []service.CategoryID{2}
+		mockStore.ProductCategories[2] = []service.CategoryID{1}
+
+		for query, ids := range map[string][]service.ProductID{
+			"category=1":                          {2},
+			"category=1&include_descendants=true": {1, 2},
+			"category=2&include_descendants=true": {1},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var productList ProductList
+			assert.Equal(t, http.StatusOK, rec.Code, query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+			var listed []service.ProductID
+			for _, product := range productList.Items {
+				listed = append(listed, product.ID)
+			}
+			assert.Equal(t, ids, listed, query)
+		}
+	})
+
+	t.Run("returns 400 for invalid category parameters", func(t *testing.T) {
+		for query, errs := range map[string][]types.FieldError{
+			"category=0":                       {{Field: "category", Tag: "min", Param: "1"}},
+			"include_descendants=true":         {{Field: "include_descendants", Tag: "required_with", Param: "category"}},
+			"category=1&include_descendants=x": {{Field: "include_descendants", Tag: "boolean"}},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/product?"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+			handlerFunc(rec, req)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, errs, problem.Errors, query)
+		}
+	})
+}
+
+func TestHandleAttributes(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	mockStore.Categories[1] = &service.Category{ID: 1, Name: "Lighting", AttributeSchema: service.AttributeSchema{
+		"voltage":  {Type: service.AttributeNumber, Required: true},
+		"dimmable": {Type: service.AttributeBoolean},
+	}}
+
+	send := func(handle apiFunc, method, target, id, payload string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(payload))
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handle)
+		handlerFunc(rec, req)
+		return rec
+	}
+
+	t.Run("creates a product with attributes", func(t *testing.T) {
+		payload := `{"sku": "LAMP-1", "name": "Lamp", "price": 30, "quantity": 1, "attributes": {"color": "red", "voltage": 230}}`
+		rec := send(handler.handleCreate, http.MethodPost, "/product/create", "", payload)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.JSONEq(t, `{"color": "red", "voltage": 230}`, string(decodeField(t, rec, "attributes")))
+		assert.Equal(t, service.Attributes{"color": "red", "voltage": 230.0}, mockStore.Products[1].Attributes)
+	})
+
+	t.Run("returns 400 for invalid attributes", func(t *testing.T) {
+		for payload, fieldErr := range map[string]types.FieldError{
+			`{"sku": "LAMP-2", "name": "Lamp", "price": 30, "quantity": 1, "attributes": {"colors": ["red"]}}`: {Field: "attributes[colors]", Tag: "attributevalue"},
+			`{"sku": "LAMP-2", "name": "Lamp", "price": 30, "quantity": 1, "attributes": {"color": null}}`:     {Field: "attributes[color]", Tag: "attributevalue"},
+			`{"sku": "LAMP-2", "name": "Lamp", "price": 30, "quantity": 1, "attributes": {"a.b": "c"}}`:        {Field: "attributes[a.b]", Tag: "attribute"},
+		} {
+			rec := send(handler.handleCreate, http.MethodPost, "/product/create", "", payload)
+
+			var problem types.APIError
+			assert.Equal(t, http.StatusBadRequest, rec.Code, payload)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, []types.FieldError{fieldErr}, problem.Errors, payload)
+		}
+	})
+
+	t.Run("returns 422 when linking categories whose schemas the attributes violate", func(t *testing.T) {
+		mockStore.Products[1].Attributes = service.Attributes{"voltage": "high", "color": "red"}
+		rec := send(handler.handleSetCategories, http.MethodPost, "/product/1/categories", "1", `{"categoryIds": [1]}`)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "attributes[voltage]", Tag: "type", Param: "number"}}, problem.Errors)
+		assert.Empty(t, mockStore.ProductCategories[1])
+
+		mockStore.Products[1].Attributes = service.Attributes{"voltage": 230.0, "color": "red"}
+		assert.Equal(t, http.StatusOK, send(handler.handleSetCategories, http.MethodPost, "/product/1/categories", "1", `{"categoryIds": [1]}`).Code)
+	})
+
+	t.Run("checks updated attributes against the category schemas", func(t *testing.T) {
+		rec := send(handler.handleUpdate, http.MethodPut, "/product/update", "", `{"id": 1, "attributes": {"color": "blue"}}`)
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "attributes[voltage]", Tag: "required"}}, problem.Errors)
+		assert.Equal(t, service.Attributes{"voltage": 230.0, "color": "red"}, mockStore.Products[1].Attributes)
+
+		rec = send(handler.handleUpdate, http.MethodPut, "/product/update", "", `{"id": 1, "attributes": {"voltage": 110, "dimmable": true}}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, service.Attributes{"voltage": 110.0, "dimmable": true}, mockStore.Products[1].Attributes)
+
+		rec = send(handler.handleUpdate, http.MethodPut, "/product/update", "", `{"id": 1, "name": "Desk Lamp"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, service.Attributes{"voltage": 110.0, "dimmable": true}, mockStore.Products[1].Attributes)
+	})
+
+	t.Run("filters products by attribute", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, Name: "Bulb", Attributes: service.Attributes{"voltage": 230.0, "dimmable": false}}
+		mockStore.Products[3] = &service.Product{ID: 3, Name: "Shade", Attributes: service.Attributes{"color": "red"}}
+
+		for query, ids := range map[string][]service.ProductID{
+			"attr.voltage=110":                    {1},
+			"attr.dimmable=false":                 {2},
+			"attr.color=red":                      {3},
+			"attr.voltage=230&attr.dimmable=true": nil,
+		} {
+			rec := send(handler.handleRetrieveAll, http.MethodGet, "/product?"+query, "", "")
+
+			var productList ProductList
+			assert.Equal(t, http.StatusOK, rec.Code, query)
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&productList))
+			var listed []service.ProductID
+			for _, product := range productList.Items {
+				listed = append(listed, product.ID)
+			}
+			assert.Equal(t, ids, listed, query)
+		}
+	})
+
+	t.Run("returns 400 for an invalid attribute parameter", func(t *testing.T) {
+		rec := send(handler.handleRetrieveAll, http.MethodGet, "/product?attr.=red&attr.color=red", "", "")
+
+		var problem types.APIError
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+		assert.Equal(t, []types.FieldError{{Field: "attr.", Tag: "attribute"}}, problem.Errors)
+	})
+}
+
+func TestHandleVariants(t *testing.T) {
+	handler, mockStore := setupTestProductHandler()
+
+	mockStore.Products[1] = &service.Product{ID: 1, Name: "Shirt", Price: service.Money{Amount: 1999, Currency: "EUR"}}
+
+	serve := func(handlerFunc apiFunc, method, id, variantID, payload string) *httptest.ResponseRecorder {
+		target := "/product/" + id + "/variants"
+		if variantID != "" {
+			target += "/" + variantID
+		}
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(payload))
+		req.SetPathValue("id", id)
+		req.SetPathValue("variantId", variantID)
+		rec := httptest.NewRecorder()
+
+		makeHTTPHandleFunc(handlerFunc)(rec, req)
+		return rec
+	}
+
+	t.Run("creates variants with and without a price override", func(t *testing.T) {
+		rec := serve(handler.handleCreateVariant, http.MethodPost, "1", "", `{"sku": "SHIRT-S", "size": "S", "color": "red", "quantity": 4}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "null", string(decodeField(t, rec, "price")))
+
+		rec = serve(handler.handleCreateVariant, http.MethodPost, "1", "", `{"sku": "SHIRT-XL", "size": "XL", "price": "24.99", "quantity": 2}`)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `"24.99"`, string(decodeField(t, rec, "price")))
+		assert.Equal(t, `"EUR"`, string(decodeField(t, rec, "currency")))
+
+		require.Len(t, mockStore.Variants, 2)
+		assert.Equal(t, 4, mockStore.Variants[1].Quantity)
+	})
+
+	t.Run("returns 400 for an invalid variant", func(t *testing.T) {
+		rec := serve(handler.handleCreateVariant, http.MethodPost, "1", "", `{"price": "-1", "quantity": -1}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `[
+			{"field": "sku", "tag": "required"},
+			{"field": "price", "tag": "gt", "param": "0"},
+			{"field": "quantity", "tag": "gte", "param": "0"}
+		]`, string(decodeField(t, rec, "errors")))
+	})
+
+	t.Run("returns 409 for a duplicate SKU", func(t *testing.T) {
+		rec := serve(handler.handleCreateVariant, http.MethodPost, "1", "", `{"sku": "SHIRT-S"}`)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Len(t, mockStore.Variants, 2)
+	})
+
+	t.Run("returns 404 for an unknown product", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(handler.handleCreateVariant, http.MethodPost, "999", "", `{"sku": "GHOST"}`).Code)
+		assert.Equal(t, http.StatusNotFound, serve(handler.handleRetrieveVariants, http.MethodGet, "999", "", "").Code)
+	})
+
+	t.Run("lists and retrieves the variants", func(t *testing.T) {
+		rec := serve(handler.handleRetrieveVariants, http.MethodGet, "1", "", "")
+
+		var variants []*service.Variant
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&variants))
+		require.Len(t, variants, 2)
+		assert.Equal(t, "SHIRT-S", variants[0].SKU)
+		assert.Equal(t, "SHIRT-XL", variants[1].SKU)
+
+		rec = serve(handler.handleRetrieveVariant, http.MethodGet, "1", "2", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"SHIRT-XL"`, string(decodeField(t, rec, "sku")))
+
+		assert.Equal(t, http.StatusNotFound, serve(handler.handleRetrieveVariant, http.MethodGet, "1", "99", "").Code)
+	})
+
+	t.Run("replaces the details of a variant", func(t *testing.T) {
+		rec := serve(handler.handleUpdateVariant, http.MethodPost, "1", "2", `{"sku": "SHIRT-XXL", "size": "XXL"}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "SHIRT-XXL", mockStore.Variants[2].SKU)
+		assert.Nil(t, mockStore.Variants[2].Price)
+		assert.Equal(t, 2, mockStore.Variants[2].Quantity)
+	})
+
+	t.Run("adjusts the stock of a variant", func(t *testing.T) {
+		rec := serve(handler.handleVariantStockIncrement, http.MethodPost, "1", "1", `{"amount": 3}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 7, mockStore.Variants[1].Quantity)
+
+		rec = serve(handler.handleVariantStockDecrement, http.MethodPost, "1", "1", `{"amount": 7}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 0, mockStore.Variants[1].Quantity)
+	})
+
+	t.Run("returns 422 if the stock of the variant is insufficient", func(t *testing.T) {
+		rec := serve(handler.handleVariantStockDecrement, http.MethodPost, "1", "2", `{"amount": 3}`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.JSONEq(t, `{"productId": 1, "variantId": 2, "requested": 3, "available": 2}`, string(decodeField(t, rec, "details")))
+		assert.Equal(t, 2, mockStore.Variants[2].Quantity)
+	})
+
+	t.Run("deletes a variant", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(handler.handleDeleteVariant, http.MethodDelete, "1", "1", "").Code)
+		assert.NotContains(t, mockStore.Variants, service.VariantID(1))
+		assert.Equal(t, http.StatusNotFound, serve(handler.handleDeleteVariant, http.MethodDelete, "1", "1", "").Code)
+	})
+}
+
 func TestHandleDelete(t *testing.T) {
 	handler, mockStore := setupTestProductHandler()
 
@@ -256,6 +1370,34 @@ func TestHandleDelete(t *testing.T) {
 		assert.False(t, exists)
 	})
 
+	t.Run("returns 412 if If-Match is stale", func(t *testing.T) {
+		mockStore.Products[2] = &service.Product{ID: 2, Name: "Versioned Product", Version: 2}
+
+		req := httptest.NewRequest(http.MethodDelete, "/product/delete/2", nil)
+		req.SetPathValue("id", "2")
+		req.Header.Set("If-Match", `"1"`)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleDelete)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Contains(t, mockStore.Products, int64(2))
+	})
+
+	t.Run("deletes a product matching If-Match", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/product/delete/2", nil)
+		req.SetPathValue("id", "2")
+		req.Header.Set("If-Match", `"2"`)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleDelete)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotContains(t, mockStore.Products, int64(2))
+	})
+
 	t.Run("returns 404 if product to delete not found", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodDelete, "/product/delete/999", nil)
 		req.SetPathValue("id", "999")
@@ -267,3 +1409,31 @@ func TestHandleDelete(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, rec.Code)
 	})
 }
+
+func TestStoreError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/product/update", nil)
+
+	testCases := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("product with id 1: %w", storage.ErrNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: duplicate entry", storage.ErrConflict), http.StatusConflict},
+		{fmt.Errorf("product with id 1: %w", storage.ErrInsufficientStock), http.StatusUnprocessableEntity},
+		{fmt.Errorf("product with id 1: %w", storage.ErrVersionMismatch), http.StatusPreconditionFailed},
+		{fmt.Errorf("%w: connection refused", storage.ErrUnavailable), http.StatusServiceUnavailable},
+		{errors.New("db error"), http.StatusInternalServerError},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.err.Error(), func(t *testing.T) {
+			apiErr := storeError(req, "Product not updated", testCase.err)
+
+			assert.Equal(t, testCase.code, apiErr.Status)
+			assert.Equal(t, http.StatusText(testCase.code), apiErr.Title)
+			assert.Equal(t, "Product not updated", apiErr.Detail)
+			assert.Equal(t, "/product/update", apiErr.Instance)
+			assert.ErrorIs(t, apiErr, testCase.err)
+		})
+	}
+}
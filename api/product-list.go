@@ -0,0 +1,281 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/types"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseProductQuery parses the query parameters of a product listing into a ProductQuery:
+//   - page and limit, or after and limit, select the page, limit being at most service.MaxPageLimit;
+//   - name_contains, min_price, max_price and in_stock filter the products;
+//   - category filters the products linked to a category, and include_descendants extends it to the category's descendants,
+//     which are resolved by the caller;
+//   - tag, repeatable, filters the products carrying all of the tags, or any of them with match=any;
+//   - attr.<name> filters the products having the attribute with the given value, numbers and booleans being
+//     written as in JSON (e.g., attr.color=red&attr.voltage=230);
+//   - sort orders the products by a comma separated list of sort keys, each prefixed with "-" for descending order.
+//
+// Missing parameters fall back to their defaults, while invalid ones are rejected with a Bad Request error
+// listing every invalid parameter.
+func parseProductQuery(r *http.Request) (*service.ProductQuery, error) {
+	params := r.URL.Query()
+	query := &service.ProductQuery{Page: 1, Limit: service.DefaultPageLimit}
+
+	var fieldErrs []types.FieldError
+	invalid := func(field, tag, param string) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: field, Tag: tag, Param: param})
+	}
+
+	query.Page = parsePositiveIntParam(params, "page", query.Page, invalid)
+	query.Limit = parseLimitParam(params, query.Limit, invalid)
+
+	query.Filter.NameContains = params.Get("name_contains")
+	query.Filter.MinPrice = parsePriceParam(params, "min_price", invalid)
+	query.Filter.MaxPrice = parsePriceParam(params, "max_price", invalid)
+	if query.Filter.MinPrice != nil && query.Filter.MaxPrice != nil && query.Filter.MinPrice.Compare(*query.Filter.MaxPrice) > 0 {
+		invalid("max_price", "gtefield", "min_price")
+	}
+
+	if inStockParam := params.Get("in_stock"); inStockParam != "" {
+		inStock, err := strconv.ParseBool(inStockParam)
+		if err != nil {
+			invalid("in_stock", "boolean", "")
+		}
+		query.Filter.InStock = &inStock
+	}
+
+	if categoryParam := params.Get("category"); categoryParam != "" {
+		categoryID, err := strconv.ParseInt(categoryParam, 10, 64)
+		if err != nil || categoryID < 1 {
+			invalid("category", "min", "1")
+		}
+		query.Filter.CategoryIDs = []service.CategoryID{service.CategoryID(categoryID)}
+	}
+
+	if includeDescendantsParam := params.Get("include_descendants"); includeDescendantsParam != "" {
+		if _, err := strconv.ParseBool(includeDescendantsParam); err != nil {
+			invalid("include_descendants", "boolean", "")
+		} else if !params.Has("category") {
+			invalid("include_descendants", "required_with", "category")
+		}
+	}
+
+	for _, tag := range params["tag"] {
+		if !service.IsValidTag(tag) {
+			invalid("tag", "tag", "")
+			break
+		}
+	}
+	query.Filter.Tags = service.NormalizeTags(params["tag"])
+
+	if matchParam := params.Get("match"); matchParam != "" {
+		switch {
+		case matchParam != "all" && matchParam != "any":
+			invalid("match", "oneof", "all any")
+		case !params.Has("tag"):
+			invalid("match", "required_with", "tag")
+		}
+		query.Filter.MatchAnyTag = matchParam == "any"
+	}
+
+	query.Filter.Attributes = parseAttributeParams(params, invalid)
+
+	if sortParam := params.Get("sort"); sortParam != "" {
+		sort, err := service.ParseSort(sortParam)
+		if err != nil {
+			invalid("sort", "oneof", strings.Join(sortKeyNames(), " "))
+		}
+		query.Sort = sort
+	}
+
+	if params.Has("page") && params.Has("after") {
+		invalid("after", "excluded_with", "page")
+	} else if afterParam := params.Get("after"); afterParam != "" {
+		// The cursor is only decodable for the order it was created for.
+		after, err := service.DecodeCursor(afterParam, query.OrderBy())
+		if err != nil {
+			invalid("after", "cursor", "")
+		}
+		query.After = after
+	}
+
+	if len(fieldErrs) > 0 {
+		apiErr := types.NewAPIError(http.StatusBadRequest, "Invalid query parameters", r.URL.Path, nil)
+		apiErr.Errors = fieldErrs
+		return nil, apiErr
+	}
+
+	return query, nil
+}
+
+// parsePositiveIntParam parses an optional, positive integer query parameter, reporting it through invalid if malformed.
+// The fallback is returned if the parameter is missing.
+func parsePositiveIntParam(params url.Values, name string, fallback int, invalid func(field, tag, param string)) int {
+	param := params.Get(name)
+	if param == "" {
+		return fallback
+	}
+
+	value, err := strconv.Atoi(param)
+	if err != nil || value < 1 {
+		invalid(name, "min", "1")
+	}
+
+	return value
+}
+
+// parseLimitParam parses the optional limit query parameter, between 1 and service.MaxPageLimit, reporting it through
+// invalid if malformed or too large. The fallback is returned if the parameter is missing.
+func parseLimitParam(params url.Values, fallback int, invalid func(field, tag, param string)) int {
+	limit := parsePositiveIntParam(params, "limit", fallback, invalid)
+	if limit > service.MaxPageLimit {
+		invalid("limit", "max", strconv.Itoa(service.MaxPageLimit))
+	}
+
+	return limit
+}
+
+// parsePriceParam parses an optional, non-negative decimal price query parameter, reporting it through invalid if malformed.
+func parsePriceParam(params url.Values, name string, invalid func(field, tag, param string)) *service.Money {
+	priceParam := params.Get(name)
+	if priceParam == "" {
+		return nil
+	}
+
+	price, err := service.ParseMoney(priceParam, service.DefaultCurrency)
+	if err != nil {
+		invalid(name, "number", "")
+		return nil
+	}
+	if price.IsNegative() {
+		invalid(name, "min", "0")
+	}
+
+	return &price
+}
+
+// parseAttributeParams parses the attr.<name> query parameters into the attribute filter, reporting invalid
+// attribute names through invalid. It returns nil if there are no such parameters.
+func parseAttributeParams(params url.Values, invalid func(field, tag, param string)) map[string]string {
+	var names []string
+	for param := range params {
+		if name, found := strings.CutPrefix(param, "attr."); found {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var attributes map[string]string
+	for _, name := range names {
+		if !service.IsValidAttributeName(name) {
+			invalid("attr."+name, "attribute", "")
+			continue
+		}
+
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[name] = params.Get("attr." + name)
+	}
+
+	return attributes
+}
+
+// sortKeyNames returns the names of the keys products can be sorted by.
+func sortKeyNames() []string {
+	var names []string
+	for _, key := range service.SortKeys {
+		names = append(names, string(key))
+	}
+
+	return names
+}
+
+// ProductList is the envelope of a product listing, describing the returned page and the whole listing.
+type ProductList struct {
+	Items      []*service.Product `json:"items"`                 // Items are the products of the requested page.
+	Page       int                `json:"page,omitempty"`        // Page is the number of the returned page, omitted for keyset pagination.
+	Limit      int                `json:"limit"`                 // Limit is the maximum number of products per page.
+	Total      int                `json:"total"`                 // Total is the number of products in the listing.
+	TotalPages int                `json:"totalPages"`            // TotalPages is the number of pages of the listing.
+	NextCursor string             `json:"next_cursor,omitempty"` // NextCursor continues a keyset paginated listing after Items, omitted on the last page.
+}
+
+// newProductList creates an empty ProductList for the page requested by the query.
+//
+// Parameters:
+// - query: The query of the listing, with a valid page and limit.
+// - total: The number of products in the listing.
+//
+// Returns:
+// - A pointer to the ProductList, whose items are to be filled in.
+func newProductList(query *service.ProductQuery, total int) *ProductList {
+	return &ProductList{
+		Items:      []*service.Product{},
+		Page:       query.Page,
+		Limit:      query.Limit,
+		Total:      total,
+		TotalPages: (total + query.Limit - 1) / query.Limit,
+	}
+}
+
+// setPaginationHeaders sets the X-Total-Count header and the RFC 8288 Link header of a product listing.
+// Offset paginated listings link to their first, previous, next and last pages, while keyset paginated
+// listings link to their first and next pages, as cursors cannot go backwards.
+//
+// Parameters:
+// - w: The http.ResponseWriter the headers are set on.
+// - r: The request of the listing, whose URL the links are based on.
+// - productList: The listing being returned.
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, productList *ProductList) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(productList.Total))
+
+	var links []string
+	addLink := func(rel string, params map[string]string) {
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, listingURL(r, params), rel))
+	}
+
+	limit := strconv.Itoa(productList.Limit)
+
+	if productList.Page == 0 {
+		addLink("first", map[string]string{"after": "", "limit": limit})
+		if productList.NextCursor != "" {
+			addLink("next", map[string]string{"after": productList.NextCursor, "limit": limit})
+		}
+	} else {
+		lastPage := max(productList.TotalPages, 1)
+
+		addLink("first", map[string]string{"page": "1", "limit": limit})
+		if productList.Page > 1 {
+			addLink("prev", map[string]string{"page": strconv.Itoa(min(productList.Page-1, lastPage)), "limit": limit})
+		}
+		if productList.Page < productList.TotalPages {
+			addLink("next", map[string]string{"page": strconv.Itoa(productList.Page + 1), "limit": limit})
+		}
+		addLink("last", map[string]string{"page": strconv.Itoa(lastPage), "limit": limit})
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// listingURL builds the URL of another page of the listing requested by r, as seen by the client,
+// keeping every query parameter except the ones overridden by params.
+func listingURL(r *http.Request, params map[string]string) string {
+	requestURL, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		requestURL = r.URL
+	}
+
+	query := requestURL.Query()
+	for name, value := range params {
+		query.Set(name, value)
+	}
+
+	return (&url.URL{Path: requestURL.Path, RawQuery: query.Encode()}).String()
+}
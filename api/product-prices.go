@@ -0,0 +1,26 @@
+package api
+
+import (
+	"cmp"
+	"ntsiris/product-microservice/internal/service"
+)
+
+// PriceHistory is the envelope of a product's prices, listing its past price changes and the pending ones.
+type PriceHistory struct {
+	ProductID service.ProductID         `json:"productId"` // ProductID is the ID of the product.
+	Price     service.Money             `json:"price"`     // Price is the current price of the product.
+	Currency  service.Currency          `json:"currency"`  // Currency is the currency of the current price.
+	Changes   []*service.PriceChange    `json:"changes"`   // Changes are the recorded price changes, the most recent first.
+	Scheduled []*service.ScheduledPrice `json:"scheduled"` // Scheduled are the pending price changes, the earliest first.
+}
+
+// newPriceHistory creates the PriceHistory of the product, with empty lists of changes.
+func newPriceHistory(product *service.Product) *PriceHistory {
+	return &PriceHistory{
+		ProductID: product.ID,
+		Price:     product.Price,
+		Currency:  cmp.Or(product.Price.Currency, service.DefaultCurrency),
+		Changes:   []*service.PriceChange{},
+		Scheduled: []*service.ScheduledPrice{},
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/types"
+)
+
+// SearchResults is the envelope of a full-text search, listing the returned page of matching products.
+type SearchResults struct {
+	Query string               `json:"query"` // Query is the searched text.
+	Items []*service.SearchHit `json:"items"` // Items are the matching products of the requested page, the most relevant first.
+	Page  int                  `json:"page"`  // Page is the number of the returned page.
+	Limit int                  `json:"limit"` // Limit is the maximum number of products per page.
+}
+
+// parseSearchQuery parses the query parameters of a full-text search into a SearchQuery:
+// q is the search text, which must contain at least one term, while page and limit select the page of results.
+// Invalid parameters are rejected with a Bad Request error listing every invalid parameter.
+func parseSearchQuery(r *http.Request) (*service.SearchQuery, error) {
+	params := r.URL.Query()
+	query := &service.SearchQuery{Text: params.Get("q"), Page: 1, Limit: service.DefaultPageLimit}
+
+	var fieldErrs []types.FieldError
+	invalid := func(field, tag, param string) {
+		fieldErrs = append(fieldErrs, types.FieldError{Field: field, Tag: tag, Param: param})
+	}
+
+	if len(service.SearchTerms(query.Text)) == 0 {
+		invalid("q", "required", "")
+	}
+	query.Page = parsePositiveIntParam(params, "page", query.Page, invalid)
+	query.Limit = parseLimitParam(params, query.Limit, invalid)
+
+	if len(fieldErrs) > 0 {
+		apiErr := types.NewAPIError(http.StatusBadRequest, "Invalid query parameters", r.URL.Path, nil)
+		apiErr.Errors = fieldErrs
+		return nil, apiErr
+	}
+
+	return query, nil
+}
@@ -1,49 +1,126 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"ntsiris/product-microservice/internal/config"
 	"ntsiris/product-microservice/internal/storage"
+	"time"
 )
 
 // APIServer represents the server for handling API requests.
-// It contains configuration for the server's address and a reference to the storage layer.
+// It owns the underlying HTTP server, the health handler reporting its readiness and a reference to the storage layer.
 type APIServer struct {
-	address string
-	store   storage.ProductStore
+	httpServer      *http.Server
+	healthHandler   *HealthHandler
+	requestTimeout  time.Duration
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	store           storage.ProductStore
 }
 
-// NewAPIServer initializes a new APIServer with the specified address and product storage layer.
+// NewAPIServer initializes a new APIServer with the specified configuration and product storage layer.
 //
 // Parameters:
-// - address: The network address the server listens on.
+// - serverConfig: The API server configuration, providing the listening address, the request timeout and the shutdown grace period.
 // - store: The storage layer used by the server to manage product data.
 //
 // Returns:
 // - A pointer to the newly created APIServer instance.
-func NewAPIServer(address string, store storage.ProductStore) *APIServer {
-	return &APIServer{
-		address: address,
-		store:   store,
+func NewAPIServer(serverConfig *config.APIServerConfig, store storage.ProductStore) *APIServer {
+	server := &APIServer{
+		healthHandler:   NewHealthHandler(store),
+		requestTimeout:  serverConfig.RequestTimeout,
+		shutdownTimeout: serverConfig.ShutdownTimeout,
+		drainDelay:      serverConfig.ShutdownDrainDelay,
+		store:           store,
 	}
+
+	server.httpServer = &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", serverConfig.PublicHost, serverConfig.Port),
+		Handler: server.Handler(),
+	}
+
+	return server
 }
 
-// Run starts the API server, setting up routing and initializing the HTTP server.
-//
-// This method configures routing for the API, registers the product handler routes,
-// and starts listening for incoming HTTP requests at the specified address.
+// Handler builds the HTTP handler serving the API, with every product, exchange rate, category and tag route mounted under the /api/v1 prefix
+// and the health routes mounted at the root, where orchestrators probe them. Every request is tagged with
+// a request ID, recovered from panics and bounded by the request timeout.
 //
 // Returns:
-// - An error if the server fails to start or encounters issues while running.
-func (server *APIServer) Run() error {
+// - An http.Handler routing requests to the registered API handlers.
+func (server *APIServer) Handler() http.Handler {
 	router := http.NewServeMux()
 
 	productHandler := NewProductHandler(server.store)
 	productHandler.RegisterRoutes(router)
 
+	exchangeRateHandler := NewExchangeRateHandler(server.store)
+	exchangeRateHandler.RegisterRoutes(router)
+
+	categoryHandler := NewCategoryHandler(server.store)
+	categoryHandler.RegisterRoutes(router)
+
+	tagHandler := NewTagHandler(server.store)
+	tagHandler.RegisterRoutes(router)
+
 	subRouter := http.NewServeMux()
 	subRouter.Handle("/api/v1/", http.StripPrefix("/api/v1", router))
 
-	log.Printf("Product API Server running on address: %s\n", server.address)
-	return http.ListenAndServe(server.address, subRouter)
+	server.healthHandler.RegisterRoutes(subRouter)
+
+	return withRequestID(withRecovery(withTimeout(subRouter, server.requestTimeout)))
+}
+
+// Run starts the API server, listening for incoming HTTP requests at the configured address.
+// It blocks until the server fails or is stopped by Shutdown.
+//
+// Returns:
+// - An error if the server fails to start or encounters issues while running; nil once it has been shut down.
+func (server *APIServer) Run() error {
+	log.Printf("Product API Server running on address: %s\n", server.httpServer.Addr)
+
+	if err := server.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown gracefully stops the API server. Readiness is reported as failing first, and the server keeps serving for
+// the configured drain delay so that load balancers notice and stop routing new traffic to it. The listeners are then
+// closed and in-flight requests are given the configured grace period to complete.
+// Connections still active once the grace period or the context expires are closed forcibly.
+//
+// Parameters:
+// - ctx: The context bounding the shutdown, in addition to the grace period.
+//
+// Returns:
+// - An error if the in-flight requests did not complete in time; otherwise, nil.
+func (server *APIServer) Shutdown(ctx context.Context) error {
+	server.healthHandler.markShuttingDown()
+
+	if server.drainDelay > 0 {
+		select {
+		case <-time.After(server.drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if server.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, server.shutdownTimeout)
+		defer cancel()
+	}
+
+	if err := server.httpServer.Shutdown(ctx); err != nil {
+		server.httpServer.Close()
+		return fmt.Errorf("error: could not drain in-flight requests: %v", err)
+	}
+
+	return nil
 }
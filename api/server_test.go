@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"ntsiris/product-microservice/internal/config"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestAPIServer(t *testing.T) *httptest.Server {
+	store := &storage.SQLiteStore{}
+	require.NoError(t, store.InitStore(&config.StorageConfig{Driver: storage.SQLITE_DRIVER, Name: storage.SQLITE_IN_MEMORY}))
+	require.NoError(t, store.RunMigrationUp(""))
+	t.Cleanup(func() { store.Close() })
+
+	testServer := httptest.NewServer(NewAPIServer(&config.APIServerConfig{RequestTimeout: time.Second}, store).Handler())
+	t.Cleanup(testServer.Close)
+
+	return testServer
+}
+
+func doRequest(t *testing.T, method, url, body string) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp
+}
+
+func TestAPIServerWithSQLiteStore(t *testing.T) {
+	testServer := setupTestAPIServer(t)
+	baseURL := testServer.URL + "/api/v1"
+
+	var created service.Product
+
+	t.Run("reports readiness with the applied migration", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, testServer.URL+"/health/ready", "")
+
+		var status HealthStatus
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		require.NotNil(t, status.Storage)
+		assert.Equal(t, uint(16), status.Storage.MigrationVersion)
+		assert.False(t, status.Storage.MigrationDirty)
+	})
+
+	t.Run("creates a product", func(t *testing.T) {
+		payload := `{"sku": "TEST-001", "name": "Test Product", "price": 19.99, "quantity": 10, "discount": 5.0, "description": "Test description", "attributes": {"color": "red"}}`
+		resp := doRequest(t, http.MethodPost, baseURL+"/product/create", payload)
+
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, "Test Product", created.Name)
+		assert.Equal(t, service.Money{Amount: 1999, Currency: service.DefaultCurrency}, created.Price)
+		assert.Equal(t, 10, created.Quantity)
+	})
+
+	t.Run("retrieves the created product", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, fmt.Sprintf("%s/product/%d", baseURL, created.ID), "")
+
+		var retrieved service.Product
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&retrieved))
+		assert.Equal(t, created.ID, retrieved.ID)
+		assert.Equal(t, "Test description", retrieved.Description)
+	})
+
+	t.Run("retrieves the created product by SKU", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, baseURL+"/product/sku/TEST-001", "")
+
+		var retrieved service.Product
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&retrieved))
+		assert.Equal(t, created.ID, retrieved.ID)
+		assert.Equal(t, "TEST-001", retrieved.SKU)
+	})
+
+	t.Run("rejects a duplicate SKU", func(t *testing.T) {
+		payload := `{"sku": "TEST-001", "name": "Copied Product", "price": 9.99, "quantity": 1}`
+		resp := doRequest(t, http.MethodPost, baseURL+"/product/create", payload)
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("lists products", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, baseURL+"/product?page=1&limit=10", "")
+
+		var productList ProductList
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&productList))
+		assert.Len(t, productList.Items, 1)
+		assert.Equal(t, 1, productList.Total)
+		assert.Equal(t, "1", resp.Header.Get("X-Total-Count"))
+		assert.Contains(t, resp.Header.Get("Link"), `</api/v1/product?limit=10&page=1>; rel="first"`)
+	})
+
+	t.Run("lists products by attribute", func(t *testing.T) {
+		for query, total := range map[string]int{"attr.color=red": 1, "attr.color=blue": 0} {
+			resp := doRequest(t, http.MethodGet, baseURL+"/product?"+query, "")
+
+			var productList ProductList
+			assert.Equal(t, http.StatusOK, resp.StatusCode, query)
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&productList))
+			assert.Equal(t, total, productList.Total, query)
+		}
+	})
+
+	t.Run("searches products", func(t *testing.T) {
+		resp := doRequest(t, http.MethodGet, baseURL+"/product/search?q=description", "")
+
+		var searchResults SearchResults
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&searchResults))
+		require.Len(t, searchResults.Items, 1)
+		assert.Equal(t, created.ID, searchResults.Items[0].ID)
+	})
+
+	t.Run("updates the product quantity atomically", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"id": %d, "name": "Updated Product", "quantity": 4}`, created.ID)
+		resp := doRequest(t, http.MethodPut, baseURL+"/product/update/", payload)
+
+		var updated service.Product
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+		assert.Equal(t, "Updated Product", updated.Name)
+		assert.Equal(t, 4, updated.Quantity)
+	})
+
+	t.Run("rejects an update with a stale If-Match", func(t *testing.T) {
+		payload := fmt.Sprintf(`{"id": %d, "name": "Stale Product"}`, created.ID)
+		req, err := http.NewRequest(http.MethodPut, baseURL+"/product/update/", bytes.NewBufferString(payload))
+		require.NoError(t, err)
+		req.Header.Set("If-Match", fmt.Sprintf(`"%d"`, created.Version))
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	})
+
+	t.Run("deletes the product", func(t *testing.T) {
+		resp := doRequest(t, http.MethodDelete, fmt.Sprintf("%s/product/delete/%d", baseURL, created.ID), "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = doRequest(t, http.MethodGet, fmt.Sprintf("%s/product/%d", baseURL, created.ID), "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestAPIServerShutdown(t *testing.T) {
+	store := &storage.SQLiteStore{}
+	require.NoError(t, store.InitStore(&config.StorageConfig{Driver: storage.SQLITE_DRIVER, Name: storage.SQLITE_IN_MEMORY}))
+	require.NoError(t, store.RunMigrationUp(""))
+	t.Cleanup(func() { store.Close() })
+
+	server := NewAPIServer(&config.APIServerConfig{PublicHost: "127.0.0.1", Port: "0", ShutdownTimeout: time.Second}, store)
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- server.Run() }()
+
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	t.Run("stops Run without an error", func(t *testing.T) {
+		select {
+		case err := <-runErr:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after Shutdown")
+		}
+	})
+
+	t.Run("fails readiness", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		server.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
+
+func TestAPIServerShutdownDrainDelay(t *testing.T) {
+	store := &storage.SQLiteStore{}
+	require.NoError(t, store.InitStore(&config.StorageConfig{Driver: storage.SQLITE_DRIVER, Name: storage.SQLITE_IN_MEMORY}))
+	require.NoError(t, store.RunMigrationUp(""))
+	t.Cleanup(func() { store.Close() })
+
+	// Reserve a free port, as the test must reach the listener the server opens itself.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, listener.Close())
+
+	serverConfig := &config.APIServerConfig{PublicHost: "127.0.0.1", Port: port, ShutdownTimeout: time.Second, ShutdownDrainDelay: 500 * time.Millisecond}
+	server := NewAPIServer(serverConfig, store)
+	go server.Run()
+
+	readiness := func() int {
+		resp, err := http.Get("http://127.0.0.1:" + port + "/health/ready")
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Eventually(t, func() bool { return readiness() == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+
+	shutdownErr := make(chan error, 1)
+	start := time.Now()
+	go func() { shutdownErr <- server.Shutdown(context.Background()) }()
+
+	t.Run("fails readiness while still serving during the drain delay", func(t *testing.T) {
+		assert.Eventually(t, func() bool { return readiness() == http.StatusServiceUnavailable }, 400*time.Millisecond, 10*time.Millisecond)
+	})
+
+	t.Run("closes the listener once the drain delay elapsed", func(t *testing.T) {
+		require.NoError(t, <-shutdownErr)
+		assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, 0, readiness())
+	})
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/utils"
+)
+
+// TagHandler is an HTTP handler listing the tags products are labeled with.
+type TagHandler struct {
+	store storage.ProductStore // store provides an interface to count the tags of products.
+}
+
+// NewTagHandler creates a new TagHandler with the specified ProductStore.
+func NewTagHandler(store storage.ProductStore) *TagHandler {
+	return &TagHandler{store: store}
+}
+
+// RegisterRoutes registers the tag-related routes to the provided router.
+func (handler *TagHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /tags", makeHTTPHandleFunc(handler.handleRetrieveAll))
+}
+
+// handleRetrieveAll lists every tag in use along with the number of products carrying it, the most used first.
+func (handler *TagHandler) handleRetrieveAll(w http.ResponseWriter, r *http.Request) error {
+	tagCounts, err := handler.store.RetrieveTagCounts(r.Context())
+	if err != nil {
+		return storeError(r, "Error in tag retrieval", err)
+	}
+
+	if tagCounts == nil {
+		tagCounts = []*service.TagCount{}
+	}
+
+	return utils.WriteJSON(w, http.StatusOK, tagCounts)
+}
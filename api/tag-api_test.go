@@ -0,0 +1,54 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"ntsiris/product-microservice/internal/mocks"
+	"ntsiris/product-microservice/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleRetrieveAllTags(t *testing.T) {
+	mockStore := mocks.NewMockProductStore()
+	handler := NewTagHandler(mockStore)
+
+	t.Run("returns an empty list without tags", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("lists the tags with their usage counts, the most used first", func(t *testing.T) {
+		mockStore.Products[1] = &service.Product{ID: 1, Tags: []string{"sale", "summer"}}
+		mockStore.Products[2] = &service.Product{ID: 2, Tags: []string{"summer"}}
+
+		req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[{"tag": "summer", "count": 2}, {"tag": "sale", "count": 1}]`, rec.Body.String())
+	})
+
+	t.Run("returns 500 on store error", func(t *testing.T) {
+		mockStore.Err = errors.New("internal store error")
+		req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+		rec := httptest.NewRecorder()
+
+		handlerFunc := makeHTTPHandleFunc(handler.handleRetrieveAll)
+		handlerFunc(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		mockStore.Err = nil // Reset error for other tests
+	})
+}
@@ -1,50 +1,90 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"ntsiris/product-microservice/api"
 	"ntsiris/product-microservice/internal/config"
+	"ntsiris/product-microservice/internal/service"
 	"ntsiris/product-microservice/internal/storage"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 )
 
 func main() {
 	logFile := setUpFileLog()
-	defer resourceCleanUp(logFile)
 
-	var store = storage.MySQLStore{}
-	err := store.InitStore(&config.EnvStorageConfig)
+	if err := run(); err != nil {
+		log.Print(err)
+		resourceCleanUp(logFile)
+		os.Exit(1)
+	}
+
+	resourceCleanUp(logFile)
+}
+
+// run sets up the store, starts the price scheduler and serves the API until a SIGINT or SIGTERM is received,
+// then shuts the server down gracefully. The store is closed once every in-flight request has completed
+// and the scheduler has stopped.
+func run() error {
+	store, err := storage.NewProductStore(config.EnvStorageConfig.Driver)
+	if err != nil {
+		return fmt.Errorf("Store Selection %v", err)
+	}
+
+	err = store.InitStore(&config.EnvStorageConfig)
 	if err != nil {
-		log.Fatalf("Store Initialization %v", err)
+		return fmt.Errorf("Store Initialization %v", err)
 	}
-	defer resourceCleanUp(logFile)
+	defer resourceCleanUp(store)
 
-	if err = store.VerifyStoreConnection(); err != nil {
-		log.Fatalf("Verify Storage Connection %v", err)
+	if err = store.VerifyStoreConnection(context.Background()); err != nil {
+		return fmt.Errorf("Verify Storage Connection %v", err)
 	}
 	log.Printf("Successfully established connection to storage component")
 
 	if config.EnvAPIServerConfig.MigrateUp {
-		log.Printf("Running Up Migrations from %s", config.EnvAPIServerConfig.MigrationPath)
-		if err := store.RunMigrationUp(config.EnvAPIServerConfig.MigrationPath); err != nil {
-			log.Fatalf("Run Up Migration %v", err)
+		log.Printf("Running Up Migrations from %s", migrationPath())
+		if err := store.RunMigrationUp(migrationPath()); err != nil {
+			return fmt.Errorf("Run Up Migration %v", err)
 		}
 		log.Print("Up Migrations finished successfully!")
 	}
 
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	go serverCleanUp(&store, sigs)
-
-	apiServerAddress := fmt.Sprintf("%s:%s", config.EnvAPIServerConfig.PublicHost, config.EnvAPIServerConfig.Port)
-	apiServer := api.NewAPIServer(apiServerAddress, &store)
-	if err := apiServer.Run(); err != nil {
-		log.Fatalf("Start API server %v\n", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// The scheduler stops once ctx is done, and the store must outlive it.
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		service.RunPriceScheduler(ctx, store, config.EnvAPIServerConfig.PriceSchedulerInterval)
+	}()
+	defer func() {
+		stop()
+		<-schedulerDone
+	}()
+
+	apiServer := api.NewAPIServer(&config.EnvAPIServerConfig, store)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- apiServer.Run() }()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			return fmt.Errorf("Start API server %v", err)
+		}
+		return nil
+	case <-ctx.Done():
 	}
+
+	// Restore the default signal handling, so a second signal terminates the process immediately.
+	stop()
+	return serverCleanUp(apiServer, store)
 }
 
 func setUpFileLog() *os.File {
@@ -61,25 +101,34 @@ func setUpFileLog() *os.File {
 	return logFile
 }
 
-func serverCleanUp(store storage.ProductStore, sigs chan os.Signal) {
-	<-sigs
+// serverCleanUp drains the API server within its grace period and, if configured, rolls back the migrations.
+func serverCleanUp(apiServer *api.APIServer, store storage.ProductStore) error {
+	log.Print("Shutting down server...")
 
-	log.Print("Shuting down server...")
+	if err := apiServer.Shutdown(context.Background()); err != nil {
+		log.Printf("Shutdown API server %v", err)
+	}
 
 	if config.EnvAPIServerConfig.MigrateDown {
-		log.Printf("Running Down Migrations from %s", config.EnvAPIServerConfig.MigrationPath)
-		if err := store.RunMigrationDown(config.EnvAPIServerConfig.MigrationPath); err != nil {
-			log.Fatalf("Run Down Migration %v", err)
+		log.Printf("Running Down Migrations from %s", migrationPath())
+		if err := store.RunMigrationDown(migrationPath()); err != nil {
+			return fmt.Errorf("Run Down Migration %v", err)
 		}
 		log.Print("Down Migrations finished successfully!")
 	}
 
 	log.Print("Server Stopped!")
-	os.Exit(0)
+	return nil
+}
+
+// migrationPath returns the directory holding the migrations of the configured storage driver.
+func migrationPath() string {
+	return filepath.Join(config.EnvAPIServerConfig.MigrationPath, config.EnvStorageConfig.Driver)
 }
 
+// resourceCleanUp closes the resource, logging the failure so the remaining resources are still released.
 func resourceCleanUp(resource io.Closer) {
 	if err := resource.Close(); err != nil {
-		log.Fatal(err)
+		log.Printf("Resource Clean Up %v", err)
 	}
 }
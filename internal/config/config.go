@@ -1,13 +1,19 @@
 package config
 
+import "time"
+
 // APIServerConfig holds the configuration settings for the API server.
 type APIServerConfig struct {
-	MigrateUp     bool   // MigrateUp indicates whether database migrations should run in the upward direction on startup.
-	MigrateDown   bool   // MigrateDown indicates whether database migrations should run in the downward direction.
-	MigrationPath string // MigrationPath specifies the path where migration files are located.
-	PublicHost    string // PublicHost is the hostname or IP address where the API server is accessible.
-	Port          string // Port is the network port on which the API server listens.
-	LogFile       string // LogFile specifies the file path for storing server logs.
+	MigrateUp              bool          // MigrateUp indicates whether database migrations should run in the upward direction on startup.
+	MigrateDown            bool          // MigrateDown indicates whether database migrations should run in the downward direction.
+	MigrationPath          string        // MigrationPath specifies the path where migration files are located.
+	PublicHost             string        // PublicHost is the hostname or IP address where the API server is accessible.
+	Port                   string        // Port is the network port on which the API server listens.
+	LogFile                string        // LogFile specifies the file path for storing server logs.
+	RequestTimeout         time.Duration // RequestTimeout is the deadline applied to each request, cancelling its storage operations once exceeded.
+	ShutdownTimeout        time.Duration // ShutdownTimeout is the grace period in-flight requests are given to complete when the server shuts down.
+	ShutdownDrainDelay     time.Duration // ShutdownDrainDelay is the time readiness fails before the listeners close, for load balancers to stop routing traffic.
+	PriceSchedulerInterval time.Duration // PriceSchedulerInterval is the time between two runs of the scheduler applying due price changes; non-positive disables it.
 }
 
 // StorageConfig holds the configuration settings for the storage (database) connection.
@@ -17,4 +23,5 @@ type StorageConfig struct {
 	Password string // Password is the password used for authenticating with the storage (database).
 	Address  string // Address is the storage's (database) server address, including host and port.
 	Name     string // Name is the name of the specific storage (database) to connect to.
+	SSLMode  string // SSLMode specifies the SSL mode used for PostgreSQL connections (e.g., "disable" or "require").
 }
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,6 +16,10 @@ func TestInitAPIServerConfigFromEnv(t *testing.T) {
 	defer os.Unsetenv("MIGRATE_DOWN")
 	defer os.Unsetenv("MIGRATION_PATH")
 	defer os.Unsetenv("LOG_FILE")
+	defer os.Unsetenv("REQUEST_TIMEOUT")
+	defer os.Unsetenv("SHUTDOWN_TIMEOUT")
+	defer os.Unsetenv("SHUTDOWN_DRAIN_DELAY")
+	defer os.Unsetenv("PRICE_SCHEDULER_INTERVAL")
 
 	t.Run("environment variables are set", func(t *testing.T) {
 		os.Setenv("PUBLIC_HOST", "testhost")
@@ -23,6 +28,10 @@ func TestInitAPIServerConfigFromEnv(t *testing.T) {
 		os.Setenv("MIGRATE_DOWN", "true")
 		os.Setenv("MIGRATION_PATH", "test_migrations/")
 		os.Setenv("LOG_FILE", "test.log")
+		os.Setenv("REQUEST_TIMEOUT", "3s")
+		os.Setenv("SHUTDOWN_TIMEOUT", "30s")
+		os.Setenv("SHUTDOWN_DRAIN_DELAY", "2s")
+		os.Setenv("PRICE_SCHEDULER_INTERVAL", "5m")
 
 		config := initAPIServerConfigFromEnv()
 
@@ -32,6 +41,10 @@ func TestInitAPIServerConfigFromEnv(t *testing.T) {
 		assert.True(t, config.MigrateDown)
 		assert.Equal(t, "test_migrations/", config.MigrationPath)
 		assert.Equal(t, "test.log", config.LogFile)
+		assert.Equal(t, 3*time.Second, config.RequestTimeout)
+		assert.Equal(t, 30*time.Second, config.ShutdownTimeout)
+		assert.Equal(t, 2*time.Second, config.ShutdownDrainDelay)
+		assert.Equal(t, 5*time.Minute, config.PriceSchedulerInterval)
 	})
 
 	t.Run("default values are applied when environment variables are missing", func(t *testing.T) {
@@ -41,6 +54,10 @@ func TestInitAPIServerConfigFromEnv(t *testing.T) {
 		os.Unsetenv("MIGRATE_DOWN")
 		os.Unsetenv("MIGRATION_PATH")
 		os.Unsetenv("LOG_FILE")
+		os.Unsetenv("REQUEST_TIMEOUT")
+		os.Unsetenv("SHUTDOWN_TIMEOUT")
+		os.Unsetenv("SHUTDOWN_DRAIN_DELAY")
+		os.Unsetenv("PRICE_SCHEDULER_INTERVAL")
 
 		config := initAPIServerConfigFromEnv()
 
@@ -50,6 +67,10 @@ func TestInitAPIServerConfigFromEnv(t *testing.T) {
 		assert.False(t, config.MigrateDown)
 		assert.Equal(t, "migrations/", config.MigrationPath)
 		assert.Equal(t, "/var/log/product-api.log", config.LogFile)
+		assert.Equal(t, 10*time.Second, config.RequestTimeout)
+		assert.Equal(t, 15*time.Second, config.ShutdownTimeout)
+		assert.Equal(t, 5*time.Second, config.ShutdownDrainDelay)
+		assert.Equal(t, time.Minute, config.PriceSchedulerInterval)
 	})
 }
 
@@ -60,6 +81,7 @@ func TestInitDBConfigFromEnv(t *testing.T) {
 	defer os.Unsetenv("DB_HOST")
 	defer os.Unsetenv("DB_PORT")
 	defer os.Unsetenv("DB_NAME")
+	defer os.Unsetenv("DB_SSL_MODE")
 
 	t.Run("environment variables are set", func(t *testing.T) {
 		os.Setenv("DB_DRIVER", "postgres")
@@ -68,6 +90,7 @@ func TestInitDBConfigFromEnv(t *testing.T) {
 		os.Setenv("DB_HOST", "testhost")
 		os.Setenv("DB_PORT", "5432")
 		os.Setenv("DB_NAME", "testDB")
+		os.Setenv("DB_SSL_MODE", "require")
 
 		config := initStorageConfigFromEnv()
 
@@ -76,6 +99,7 @@ func TestInitDBConfigFromEnv(t *testing.T) {
 		assert.Equal(t, "testpass", config.Password)
 		assert.Equal(t, "testhost:5432", config.Address)
 		assert.Equal(t, "testDB", config.Name)
+		assert.Equal(t, "require", config.SSLMode)
 	})
 
 	t.Run("default values are applied when environment variables are missing", func(t *testing.T) {
@@ -85,6 +109,7 @@ func TestInitDBConfigFromEnv(t *testing.T) {
 		os.Unsetenv("DB_HOST")
 		os.Unsetenv("DB_PORT")
 		os.Unsetenv("DB_NAME")
+		os.Unsetenv("DB_SSL_MODE")
 
 		config := initStorageConfigFromEnv()
 
@@ -93,6 +118,7 @@ func TestInitDBConfigFromEnv(t *testing.T) {
 		assert.Equal(t, "root", config.Password)
 		assert.Equal(t, "localhost:3306", config.Address)
 		assert.Equal(t, "productDB", config.Name)
+		assert.Equal(t, "disable", config.SSLMode)
 	})
 }
 
@@ -129,3 +155,22 @@ func TestGetEnvBool(t *testing.T) {
 		assert.False(t, getEnvBool("TEST_BOOL", false))
 	})
 }
+
+func TestGetEnvDuration(t *testing.T) {
+	defer os.Unsetenv("TEST_DURATION")
+
+	t.Run("parses duration values correctly", func(t *testing.T) {
+		os.Setenv("TEST_DURATION", "1m30s")
+		assert.Equal(t, 90*time.Second, getEnvDuration("TEST_DURATION", time.Second))
+	})
+
+	t.Run("uses fallback when variable is invalid", func(t *testing.T) {
+		os.Setenv("TEST_DURATION", "soon")
+		assert.Equal(t, time.Second, getEnvDuration("TEST_DURATION", time.Second))
+	})
+
+	t.Run("uses fallback when variable is not set", func(t *testing.T) {
+		os.Unsetenv("TEST_DURATION")
+		assert.Equal(t, time.Second, getEnvDuration("TEST_DURATION", time.Second))
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lpernett/godotenv"
 )
@@ -32,12 +33,16 @@ func init() {
 // - An APIServerConfig struct with settings derived from environment variables or default values if not set.
 func initAPIServerConfigFromEnv() APIServerConfig {
 	return APIServerConfig{
-		PublicHost:    getEnv("PUBLIC_HOST", "localhost"),
-		Port:          getEnv("PORT", "8080"),
-		MigrateUp:     getEnvBool("MIGRATE_UP", true),
-		MigrateDown:   getEnvBool("MIGRATE_DOWN", false),
-		MigrationPath: getEnv("MIGRATION_PATH", "migrations/"),
-		LogFile:       getEnv("LOG_FILE", "/var/log/product-api.log"),
+		PublicHost:             getEnv("PUBLIC_HOST", "localhost"),
+		Port:                   getEnv("PORT", "8080"),
+		MigrateUp:              getEnvBool("MIGRATE_UP", true),
+		MigrateDown:            getEnvBool("MIGRATE_DOWN", false),
+		MigrationPath:          getEnv("MIGRATION_PATH", "migrations/"),
+		LogFile:                getEnv("LOG_FILE", "/var/log/product-api.log"),
+		RequestTimeout:         getEnvDuration("REQUEST_TIMEOUT", 10*time.Second),
+		ShutdownTimeout:        getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		ShutdownDrainDelay:     getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		PriceSchedulerInterval: getEnvDuration("PRICE_SCHEDULER_INTERVAL", time.Minute),
 	}
 }
 
@@ -52,6 +57,7 @@ func initStorageConfigFromEnv() StorageConfig {
 		Password: getEnv("DB_PASSWORD", "root"),
 		Address:  fmt.Sprintf("%s:%s", getEnv("DB_HOST", "localhost"), getEnv("DB_PORT", "3306")),
 		Name:     getEnv("DB_NAME", "productDB"),
+		SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 	}
 }
 
@@ -87,3 +93,25 @@ func getEnvBool(key string, fallback bool) bool {
 
 	return fallback
 }
+
+// getEnvDuration retrieves a duration (e.g., "10s" or "1m30s") from an environment variable.
+// If the variable is not set or cannot be parsed, it returns the provided fallback.
+//
+// Parameters:
+// - key: The name of the environment variable to retrieve.
+// - fallback: The fallback duration to return if the environment variable is not set or invalid.
+//
+// Returns:
+// - A time.Duration indicating the environment variable's value or the fallback if not set or invalid.
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if valStr, ok := os.LookupEnv(key); ok {
+		value, err := time.ParseDuration(valStr)
+		if err != nil {
+			log.Printf("Invalid duration %q for %s, using %v", valStr, key, fallback)
+			return fallback
+		}
+		return value
+	}
+
+	return fallback
+}
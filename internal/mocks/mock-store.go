@@ -1,99 +1,745 @@
 package mocks
 
 import (
-	"errors"
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
 	"ntsiris/product-microservice/internal/config"
 	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"slices"
+	"sync"
 	"time"
 )
 
 // MockProductStore simulates the ProductStore interface for testing purposes.
+// Missing products are reported with storage.ErrNotFound, like the real stores.
 type MockProductStore struct {
-	Products map[int64]*service.Product // Simulates a database
-	NextID   int64                      // Auto-increment ID for new products
-	Err      error                      // Error to simulate failures
+	Products          map[int64]*service.Product                           // Simulates a database
+	ExchangeRates     map[exchangeRateKey]*service.ExchangeRate            // Simulates the exchange_rates table
+	PriceHistory      map[service.ProductID][]*service.PriceChange         // Simulates the price_history table, oldest change first
+	ScheduledPrices   map[service.ScheduledPriceID]*service.ScheduledPrice // Simulates the scheduled_prices table
+	Categories        map[service.CategoryID]*service.Category             // Simulates the categories table
+	ProductCategories map[service.ProductID][]service.CategoryID           // Simulates the product_categories table, IDs in ascending order
+	Variants          map[service.VariantID]*service.Variant               // Simulates the variants table
+	NextID            int64                                                // Auto-increment ID for new products
+	NextScheduledID   service.ScheduledPriceID                             // Auto-increment ID for new scheduled prices
+	NextCategoryID    service.CategoryID                                   // Auto-increment ID for new categories
+	NextVariantID     service.VariantID                                    // Auto-increment ID for new variants
+	Err               error                                                // Error to simulate failures
+	mu                sync.Mutex                                           // Serializes access to Products, like database transactions
+}
+
+// exchangeRateKey identifies an exchange rate by its base and quote currency, like the exchange_rates primary key.
+type exchangeRateKey struct {
+	base, quote service.Currency
 }
 
 // NewMockProductStore initializes the mock with an empty product map.
 func NewMockProductStore() *MockProductStore {
 	return &MockProductStore{
-		Products: make(map[int64]*service.Product),
-		NextID:   1,
+		Products:          make(map[int64]*service.Product),
+		ExchangeRates:     make(map[exchangeRateKey]*service.ExchangeRate),
+		PriceHistory:      make(map[service.ProductID][]*service.PriceChange),
+		ScheduledPrices:   make(map[service.ScheduledPriceID]*service.ScheduledPrice),
+		Categories:        make(map[service.CategoryID]*service.Category),
+		ProductCategories: make(map[service.ProductID][]service.CategoryID),
+		Variants:          make(map[service.VariantID]*service.Variant),
+		NextID:            1,
+		NextScheduledID:   1,
+		NextCategoryID:    1,
+		NextVariantID:     1,
 	}
 }
 
-// Create simulates adding a new product with auto-increment ID.
-func (mock *MockProductStore) Create(product **service.Product) error {
-	if mock.Err != nil {
-		return mock.Err
+// Create simulates adding a new product with auto-increment ID, rejecting duplicate SKUs.
+func (mock *MockProductStore) Create(ctx context.Context, product **service.Product) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if err := mock.checkProductSKU(*product); err != nil {
+		return err
 	}
 	(*product).ID = service.ProductID(mock.NextID)
 	(*product).CreatedAt = time.Now()
-	(*product).LastUpdated = time.Now()
-	mock.Products[mock.NextID] = *product
+	(*product).LastUpdated = (*product).CreatedAt
+	(*product).Version = 1
+	mock.Products[mock.NextID] = copyProduct(*product)
 	mock.NextID++
 	return nil
 }
 
-// Retrieve finds a product by ID.
-func (mock *MockProductStore) Retrieve(id service.ProductID) (*service.Product, error) {
-	if mock.Err != nil {
-		return nil, mock.Err
+// Retrieve finds a product by ID, returning a copy so callers cannot alter the stored product.
+func (mock *MockProductStore) Retrieve(ctx context.Context, id service.ProductID) (*service.Product, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
 	}
 	product, exists := mock.Products[int64(id)]
 	if !exists {
-		return nil, errors.New("product not found")
+		return nil, fmt.Errorf("product with id %d: %w", id, storage.ErrNotFound)
 	}
-	return product, nil
+	return copyProduct(product), nil
 }
 
-// RetrieveAll returns all products as a paginated result.
-func (mock *MockProductStore) RetrieveAll(page, limit int) ([]*service.Product, error) {
-	if mock.Err != nil {
-		return nil, mock.Err
+// RetrieveBySKU finds a product by SKU, returning a copy so callers cannot alter the stored product.
+func (mock *MockProductStore) RetrieveBySKU(ctx context.Context, sku string) (*service.Product, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
 	}
-	var products []*service.Product
 	for _, product := range mock.Products {
-		products = append(products, product)
+		if product.SKU == sku {
+			return copyProduct(product), nil
+		}
+	}
+	return nil, fmt.Errorf("product with sku %q: %w", sku, storage.ErrNotFound)
+}
+
+// RetrieveAll returns the page of products matching the query's filter, in the query's order, like the SQL stores.
+func (mock *MockProductStore) RetrieveAll(ctx context.Context, query *service.ProductQuery) ([]*service.Product, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	page := *query
+	page.Normalize()
+	orderBy := page.OrderBy()
+
+	var matching []*service.Product
+	for _, product := range mock.Products {
+		if page.Filter.Matches(product) && mock.inCategories(product.ID, page.Filter.CategoryIDs) && (page.After == nil || page.After.Precedes(product, orderBy)) {
+			matching = append(matching, product)
+		}
+	}
+	slices.SortFunc(matching, func(a, b *service.Product) int { return service.CompareProducts(a, b, orderBy) })
+
+	offset := page.Offset()
+	var products []*service.Product
+	for _, product := range matching[min(offset, len(matching)):min(offset+page.Limit, len(matching))] {
+		products = append(products, copyProduct(product))
 	}
 	return products, nil
 }
 
-// Update modifies an existing product's details.
-func (mock *MockProductStore) Update(product **service.Product) error {
-	if mock.Err != nil {
-		return mock.Err
+// Count returns the number of stored products matching the query's filter, regardless of its pagination.
+func (mock *MockProductStore) Count(ctx context.Context, query *service.ProductQuery) (int, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, product := range mock.Products {
+		if query.Filter.Matches(product) && mock.inCategories(product.ID, query.Filter.CategoryIDs) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Search returns the page of products matching any term of the search text, the most relevant first.
+// The inverted index is rebuilt from Products on every search, so products assigned directly are found too.
+func (mock *MockProductStore) Search(ctx context.Context, query *service.SearchQuery) ([]*service.SearchHit, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
 	}
-	if _, exists := mock.Products[int64((*product).ID)]; !exists {
-		return errors.New("product not found")
+	page := *query
+	page.Normalize()
+
+	matching := newSearchIndex(mock.Products).search(service.SearchTerms(page.Text))
+
+	offset := page.Offset()
+	var hits []*service.SearchHit
+	for _, hit := range matching[min(offset, len(matching)):min(offset+page.Limit, len(matching))] {
+		hits = append(hits, &service.SearchHit{Product: copyProduct(hit.Product), Score: hit.Score})
+	}
+	return hits, nil
+}
+
+// Update modifies an existing product's details, rejecting stale versions, duplicate SKUs, quantity deltas that would
+// drive stock negative and attributes not conforming to the schemas of the product's categories.
+func (mock *MockProductStore) Update(ctx context.Context, product **service.Product) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	stored, exists := mock.Products[int64((*product).ID)]
+	if !exists {
+		return fmt.Errorf("product with id %d: %w", (*product).ID, storage.ErrNotFound)
 	}
-	(*product).LastUpdated = time.Now() // Update the LastUpdated field
-	mock.Products[int64((*product).ID)] = *product
+	if err := checkVersion(stored, (*product).Version); err != nil {
+		return err
+	}
+	if err := mock.checkProductSKU(*product); err != nil {
+		return err
+	}
+	quantityDelta := (*product).GetQuantityDelta()
+	if stored.Quantity+quantityDelta < 0 {
+		return &storage.InsufficientStockError{ProductID: stored.ID, Requested: -quantityDelta, Available: stored.Quantity}
+	}
+	categories := mock.copyCategories(func(category *service.Category) bool {
+		return slices.Contains(mock.ProductCategories[stored.ID], category.ID)
+	})
+	if attributeErrs := service.CheckAttributes((*product).Attributes, categories); attributeErrs != nil {
+		return &storage.NonconformingAttributesError{ProductID: stored.ID, Errors: attributeErrs}
+	}
+	updated := copyProduct(*product)
+	updated.CreatedAt = stored.CreatedAt
+	updated.Quantity = stored.Quantity + quantityDelta
+	updated.Version = stored.Version + 1
+	updated.LastUpdated = time.Now() // Update the LastUpdated field
+	mock.recordPriceChange(stored, updated.Price, updated.LastUpdated)
+	mock.Products[int64((*product).ID)] = updated
+	*product = copyProduct(updated)
 	return nil
 }
 
-// Delete removes a product by ID.
-func (mock *MockProductStore) Delete(product *service.Product) error {
-	if mock.Err != nil {
-		return mock.Err
+// AdjustStock adds the signed delta to a product's quantity, rejecting deltas that would drive stock negative.
+func (mock *MockProductStore) AdjustStock(ctx context.Context, id service.ProductID, quantityDelta int) (*service.Product, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
 	}
-	if _, exists := mock.Products[int64(product.ID)]; !exists {
-		return errors.New("product not found")
+	stored, exists := mock.Products[int64(id)]
+	if !exists {
+		return nil, fmt.Errorf("product with id %d: %w", id, storage.ErrNotFound)
+	}
+	if stored.Quantity+quantityDelta < 0 {
+		return nil, &storage.InsufficientStockError{ProductID: id, Requested: -quantityDelta, Available: stored.Quantity}
+	}
+	stored.Quantity += quantityDelta
+	stored.Version++
+	stored.LastUpdated = time.Now()
+	return copyProduct(stored), nil
+}
+
+// Delete removes a product by ID, rejecting stale versions.
+func (mock *MockProductStore) Delete(ctx context.Context, product *service.Product) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	stored, exists := mock.Products[int64(product.ID)]
+	if !exists {
+		return fmt.Errorf("product with id %d: %w", product.ID, storage.ErrNotFound)
+	}
+	if err := checkVersion(stored, product.Version); err != nil {
+		return err
 	}
 	delete(mock.Products, int64(product.ID))
+	delete(mock.PriceHistory, product.ID)
+	delete(mock.ProductCategories, product.ID)
+	for id, variant := range mock.Variants {
+		if variant.ProductID == product.ID {
+			delete(mock.Variants, id)
+		}
+	}
+	for id, scheduledPrice := range mock.ScheduledPrices {
+		if scheduledPrice.ProductID == product.ID {
+			delete(mock.ScheduledPrices, id)
+		}
+	}
+	return nil
+}
+
+// RetrievePriceHistory returns copies of the product's price changes, the most recent first.
+func (mock *MockProductStore) RetrievePriceHistory(ctx context.Context, id service.ProductID) ([]*service.PriceChange, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	if _, exists := mock.Products[int64(id)]; !exists {
+		return nil, fmt.Errorf("product with id %d: %w", id, storage.ErrNotFound)
+	}
+	var changes []*service.PriceChange
+	history := mock.PriceHistory[id]
+	for i := len(history) - 1; i >= 0; i-- {
+		found := *history[i]
+		changes = append(changes, &found)
+	}
+	return changes, nil
+}
+
+// SchedulePrice stores a copy of the scheduled price with an auto-increment ID.
+func (mock *MockProductStore) SchedulePrice(ctx context.Context, scheduledPrice *service.ScheduledPrice) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if _, exists := mock.Products[int64(scheduledPrice.ProductID)]; !exists {
+		return fmt.Errorf("product with id %d: %w", scheduledPrice.ProductID, storage.ErrNotFound)
+	}
+	scheduledPrice.ID = mock.NextScheduledID
+	saved := *scheduledPrice
+	mock.ScheduledPrices[saved.ID] = &saved
+	mock.NextScheduledID++
+	return nil
+}
+
+// RetrieveScheduledPrices returns copies of the product's pending price changes, the earliest first.
+func (mock *MockProductStore) RetrieveScheduledPrices(ctx context.Context, id service.ProductID) ([]*service.ScheduledPrice, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	var scheduledPrices []*service.ScheduledPrice
+	for _, scheduledPrice := range mock.ScheduledPrices {
+		if scheduledPrice.ProductID == id {
+			found := *scheduledPrice
+			scheduledPrices = append(scheduledPrices, &found)
+		}
+	}
+	slices.SortFunc(scheduledPrices, compareScheduledPrices)
+	return scheduledPrices, nil
+}
+
+// CancelScheduledPrice removes a pending price change of the product.
+func (mock *MockProductStore) CancelScheduledPrice(ctx context.Context, productID service.ProductID, id service.ScheduledPriceID) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	scheduledPrice, exists := mock.ScheduledPrices[id]
+	if !exists || scheduledPrice.ProductID != productID {
+		return fmt.Errorf("scheduled price with id %d of product with id %d: %w", id, productID, storage.ErrNotFound)
+	}
+	delete(mock.ScheduledPrices, id)
+	return nil
+}
+
+// ApplyDuePrices applies the pending price changes effective at or before now, the earliest first, like the SQL stores.
+func (mock *MockProductStore) ApplyDuePrices(ctx context.Context, now time.Time) (int, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return 0, err
+	}
+	var due []*service.ScheduledPrice
+	for _, scheduledPrice := range mock.ScheduledPrices {
+		if !scheduledPrice.EffectiveAt.After(now) {
+			due = append(due, scheduledPrice)
+		}
+	}
+	slices.SortFunc(due, compareScheduledPrices)
+
+	for _, scheduledPrice := range due {
+		if stored, exists := mock.Products[int64(scheduledPrice.ProductID)]; exists {
+			mock.recordPriceChange(stored, scheduledPrice.Price, scheduledPrice.EffectiveAt)
+			stored.Price = scheduledPrice.Price
+			stored.Version++
+			stored.LastUpdated = now
+		}
+		delete(mock.ScheduledPrices, scheduledPrice.ID)
+	}
+	return len(due), nil
+}
+
+// CreateCategory stores a copy of the category with an auto-increment ID, rejecting unknown parents like the foreign key.
+func (mock *MockProductStore) CreateCategory(ctx context.Context, category *service.Category) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if err := mock.checkParent(category); err != nil {
+		return err
+	}
+	category.ID = mock.NextCategoryID
+	mock.Categories[category.ID] = copyCategory(category)
+	mock.NextCategoryID++
+	return nil
+}
+
+// RetrieveCategory finds a category by ID, returning a copy so callers cannot alter the stored category.
+func (mock *MockProductStore) RetrieveCategory(ctx context.Context, id service.CategoryID) (*service.Category, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	category, exists := mock.Categories[id]
+	if !exists {
+		return nil, fmt.Errorf("category with id %d: %w", id, storage.ErrNotFound)
+	}
+	return copyCategory(category), nil
+}
+
+// RetrieveCategories returns copies of the stored categories, ordered by name and ID.
+func (mock *MockProductStore) RetrieveCategories(ctx context.Context) ([]*service.Category, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	return mock.copyCategories(func(*service.Category) bool { return true }), nil
+}
+
+// UpdateCategory replaces the name, parent and attribute schema of a stored category, rejecting parents among its descendants
+// and schemas the attributes of its linked products do not conform to.
+func (mock *MockProductStore) UpdateCategory(ctx context.Context, category *service.Category) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	stored, exists := mock.Categories[category.ID]
+	if !exists {
+		return fmt.Errorf("category with id %d: %w", category.ID, storage.ErrNotFound)
+	}
+	if err := mock.checkParent(category); err != nil {
+		return err
+	}
+	if category.ParentID != nil {
+		categories := mock.copyCategories(func(*service.Category) bool { return true })
+		if slices.Contains(service.DescendantIDs(categories, category.ID), *category.ParentID) {
+			return fmt.Errorf("category with id %d cannot be moved under category with id %d: %w", category.ID, *category.ParentID, storage.ErrConflict)
+		}
+	}
+	productIDs := make([]service.ProductID, 0, len(mock.ProductCategories))
+	for productID, categoryIDs := range mock.ProductCategories {
+		if slices.Contains(categoryIDs, category.ID) {
+			productIDs = append(productIDs, productID)
+		}
+	}
+	slices.Sort(productIDs)
+	for _, productID := range productIDs {
+		if attributeErrs := service.CheckAttributes(mock.Products[int64(productID)].Attributes, []*service.Category{category}); attributeErrs != nil {
+			return &storage.NonconformingAttributesError{ProductID: productID, Errors: attributeErrs}
+		}
+	}
+	saved := copyCategory(category)
+	saved.CreatedAt = stored.CreatedAt
+	mock.Categories[saved.ID] = saved
+	return nil
+}
+
+// DeleteCategory removes a category without subcategories, along with its links to products.
+func (mock *MockProductStore) DeleteCategory(ctx context.Context, id service.CategoryID) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if _, exists := mock.Categories[id]; !exists {
+		return fmt.Errorf("category with id %d: %w", id, storage.ErrNotFound)
+	}
+	for _, category := range mock.Categories {
+		if category.ParentID != nil && *category.ParentID == id {
+			return fmt.Errorf("category with id %d has subcategories: %w", id, storage.ErrConflict)
+		}
+	}
+	delete(mock.Categories, id)
+	for productID, categoryIDs := range mock.ProductCategories {
+		mock.ProductCategories[productID] = slices.DeleteFunc(categoryIDs, func(categoryID service.CategoryID) bool { return categoryID == id })
+	}
+	return nil
+}
+
+// SetProductCategories replaces the categories the product is linked to, rejecting unknown categories like the foreign key
+// and categories whose schemas the attributes of the product do not conform to.
+func (mock *MockProductStore) SetProductCategories(ctx context.Context, id service.ProductID, categoryIDs []service.CategoryID) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if _, exists := mock.Products[int64(id)]; !exists {
+		return fmt.Errorf("product with id %d: %w", id, storage.ErrNotFound)
+	}
+	for _, categoryID := range categoryIDs {
+		if _, exists := mock.Categories[categoryID]; !exists {
+			return fmt.Errorf("category with id %d: %w", categoryID, storage.ErrConflict)
+		}
+	}
+	linked := slices.Clone(categoryIDs)
+	slices.Sort(linked)
+	categories := mock.copyCategories(func(category *service.Category) bool { return slices.Contains(linked, category.ID) })
+	if attributeErrs := service.CheckAttributes(mock.Products[int64(id)].Attributes, categories); attributeErrs != nil {
+		return &storage.NonconformingAttributesError{ProductID: id, Errors: attributeErrs}
+	}
+	mock.ProductCategories[id] = slices.Compact(linked)
+	return nil
+}
+
+// RetrieveProductCategories returns copies of the categories the product is linked to, ordered by name and ID.
+func (mock *MockProductStore) RetrieveProductCategories(ctx context.Context, id service.ProductID) ([]*service.Category, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	if _, exists := mock.Products[int64(id)]; !exists {
+		return nil, fmt.Errorf("product with id %d: %w", id, storage.ErrNotFound)
+	}
+	return mock.copyCategories(func(category *service.Category) bool {
+		return slices.Contains(mock.ProductCategories[id], category.ID)
+	}), nil
+}
+
+// CreateVariant stores a copy of the variant with an auto-increment ID, rejecting unknown products and duplicate SKUs.
+func (mock *MockProductStore) CreateVariant(ctx context.Context, variant *service.Variant) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if _, exists := mock.Products[int64(variant.ProductID)]; !exists {
+		return fmt.Errorf("product with id %d: %w", variant.ProductID, storage.ErrNotFound)
+	}
+	if err := mock.checkSKU(variant); err != nil {
+		return err
+	}
+	variant.ID = mock.NextVariantID
+	mock.Variants[variant.ID] = copyVariant(variant)
+	mock.NextVariantID++
+	return nil
+}
+
+// RetrieveVariants returns copies of the variants of the product, ordered by ID.
+func (mock *MockProductStore) RetrieveVariants(ctx context.Context, productID service.ProductID) ([]*service.Variant, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	if _, exists := mock.Products[int64(productID)]; !exists {
+		return nil, fmt.Errorf("product with id %d: %w", productID, storage.ErrNotFound)
+	}
+	var variants []*service.Variant
+	for _, variant := range mock.Variants {
+		if variant.ProductID == productID {
+			variants = append(variants, copyVariant(variant))
+		}
+	}
+	slices.SortFunc(variants, func(a, b *service.Variant) int { return cmp.Compare(a.ID, b.ID) })
+	return variants, nil
+}
+
+// RetrieveVariant finds a variant of the product by ID, returning a copy so callers cannot alter the stored variant.
+func (mock *MockProductStore) RetrieveVariant(ctx context.Context, productID service.ProductID, id service.VariantID) (*service.Variant, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	stored, err := mock.findVariant(productID, id)
+	if err != nil {
+		return nil, err
+	}
+	return copyVariant(stored), nil
+}
+
+// UpdateVariant replaces the SKU, size, color and price of a stored variant, keeping its quantity and creation time.
+func (mock *MockProductStore) UpdateVariant(ctx context.Context, variant *service.Variant) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	stored, err := mock.findVariant(variant.ProductID, variant.ID)
+	if err != nil {
+		return err
+	}
+	if err := mock.checkSKU(variant); err != nil {
+		return err
+	}
+	updated := copyVariant(variant)
+	updated.Quantity = stored.Quantity
+	updated.CreatedAt = stored.CreatedAt
+	mock.Variants[variant.ID] = updated
+	*variant = *copyVariant(updated)
+	return nil
+}
+
+// DeleteVariant removes a variant of the product by ID.
+func (mock *MockProductStore) DeleteVariant(ctx context.Context, productID service.ProductID, id service.VariantID) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	if _, err := mock.findVariant(productID, id); err != nil {
+		return err
+	}
+	delete(mock.Variants, id)
+	return nil
+}
+
+// AdjustVariantStock adds the signed delta to a variant's quantity, rejecting deltas that would drive stock negative.
+func (mock *MockProductStore) AdjustVariantStock(ctx context.Context, productID service.ProductID, id service.VariantID, quantityDelta int) (*service.Variant, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	stored, err := mock.findVariant(productID, id)
+	if err != nil {
+		return nil, err
+	}
+	if stored.Quantity+quantityDelta < 0 {
+		return nil, &storage.InsufficientStockError{ProductID: productID, VariantID: id, Requested: -quantityDelta, Available: stored.Quantity}
+	}
+	stored.Quantity += quantityDelta
+	stored.LastUpdated = time.Now()
+	return copyVariant(stored), nil
+}
+
+// RetrieveTagCounts counts the products carrying every tag, the most used first, ties being ordered by tag.
+func (mock *MockProductStore) RetrieveTagCounts(ctx context.Context) ([]*service.TagCount, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, product := range mock.Products {
+		for _, tag := range service.NormalizeTags(product.Tags) {
+			counts[tag]++
+		}
+	}
+	var tagCounts []*service.TagCount
+	for tag, count := range counts {
+		tagCounts = append(tagCounts, &service.TagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(tagCounts, func(a, b *service.TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Tag, b.Tag))
+	})
+	return tagCounts, nil
+}
+
+// SaveExchangeRate stores a copy of the exchange rate, replacing any rate between the same base and quote currency.
+func (mock *MockProductStore) SaveExchangeRate(ctx context.Context, exchangeRate *service.ExchangeRate) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	saved := *exchangeRate
+	mock.ExchangeRates[exchangeRateKey{exchangeRate.Base, exchangeRate.Quote}] = &saved
+	return nil
+}
+
+// RetrieveExchangeRate finds the rate between two currencies, preferring the one stored from the first to the second.
+func (mock *MockProductStore) RetrieveExchangeRate(ctx context.Context, from service.Currency, to service.Currency) (*service.ExchangeRate, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	for _, key := range []exchangeRateKey{{from, to}, {to, from}} {
+		if exchangeRate, exists := mock.ExchangeRates[key]; exists {
+			found := *exchangeRate
+			return &found, nil
+		}
+	}
+	return nil, fmt.Errorf("exchange rate between %s and %s: %w", from, to, storage.ErrNotFound)
+}
+
+// RetrieveExchangeRates returns copies of the stored exchange rates, ordered by base and quote currency.
+func (mock *MockProductStore) RetrieveExchangeRates(ctx context.Context) ([]*service.ExchangeRate, error) {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return nil, err
+	}
+	var exchangeRates []*service.ExchangeRate
+	for _, exchangeRate := range mock.ExchangeRates {
+		found := *exchangeRate
+		exchangeRates = append(exchangeRates, &found)
+	}
+	slices.SortFunc(exchangeRates, func(a, b *service.ExchangeRate) int {
+		return cmp.Or(cmp.Compare(a.Base, b.Base), cmp.Compare(a.Quote, b.Quote))
+	})
+	return exchangeRates, nil
+}
+
+// DeleteExchangeRate removes the rate stored from the base to the quote currency.
+func (mock *MockProductStore) DeleteExchangeRate(ctx context.Context, base service.Currency, quote service.Currency) error {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+
+	if err := mock.check(ctx); err != nil {
+		return err
+	}
+	key := exchangeRateKey{base, quote}
+	if _, exists := mock.ExchangeRates[key]; !exists {
+		return fmt.Errorf("exchange rate from %s to %s: %w", base, quote, storage.ErrNotFound)
+	}
+	delete(mock.ExchangeRates, key)
 	return nil
 }
 
+// check returns the simulated failure, if any, or the context error once the context is done.
+func (mock *MockProductStore) check(ctx context.Context) error {
+	if mock.Err != nil {
+		return mock.Err
+	}
+	return ctx.Err()
+}
+
 // InitStore will not be tested since it can not be mocked.
 func (mock *MockProductStore) InitStore(config *config.StorageConfig) error {
 	return mock.Err
 }
 
 // VerifyStoreConnection will not be tested since it can not be mocked.
-func (mock *MockProductStore) VerifyStoreConnection() error {
+func (mock *MockProductStore) VerifyStoreConnection(ctx context.Context) error {
 	return mock.Err
 }
 
+// MigrationVersion simulates a store without migrations, failing with the simulated error if any.
+func (mock *MockProductStore) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	return 0, false, mock.Err
+}
+
 // Close will not be tested since it can not be mocked.
 func (mock *MockProductStore) Close() error {
 	return mock.Err
@@ -108,3 +754,133 @@ func (mock *MockProductStore) RunMigrationUp(path string) error {
 func (mock *MockProductStore) RunMigrationDown(path string) error {
 	return mock.Err
 }
+
+// recordPriceChange appends a change of the stored product's price to its history, unless the price is unchanged.
+func (mock *MockProductStore) recordPriceChange(stored *service.Product, price service.Money, changedAt time.Time) {
+	if price == stored.Price {
+		return
+	}
+	mock.PriceHistory[stored.ID] = append(mock.PriceHistory[stored.ID], &service.PriceChange{
+		ProductID:     stored.ID,
+		PreviousPrice: stored.Price,
+		Price:         price,
+		ChangedAt:     changedAt,
+	})
+}
+
+// checkParent rejects a category whose parent is not stored, like the categories foreign key.
+func (mock *MockProductStore) checkParent(category *service.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if _, exists := mock.Categories[*category.ParentID]; !exists {
+		return fmt.Errorf("parent category with id %d: %w", *category.ParentID, storage.ErrConflict)
+	}
+	return nil
+}
+
+// copyCategories returns copies of the stored categories kept by the predicate, ordered by name and ID.
+func (mock *MockProductStore) copyCategories(keep func(*service.Category) bool) []*service.Category {
+	var categories []*service.Category
+	for _, category := range mock.Categories {
+		if keep(category) {
+			categories = append(categories, copyCategory(category))
+		}
+	}
+	slices.SortFunc(categories, service.CompareCategories)
+	return categories
+}
+
+// inCategories reports whether the product is linked to any of the categories, or the categories are empty.
+func (mock *MockProductStore) inCategories(id service.ProductID, categoryIDs []service.CategoryID) bool {
+	if len(categoryIDs) == 0 {
+		return true
+	}
+	for _, categoryID := range mock.ProductCategories[id] {
+		if slices.Contains(categoryIDs, categoryID) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProductSKU rejects a product whose SKU belongs to another product, like the products unique key.
+// Empty SKUs are not checked, so tests may store products without one.
+func (mock *MockProductStore) checkProductSKU(product *service.Product) error {
+	if product.SKU == "" {
+		return nil
+	}
+	for _, stored := range mock.Products {
+		if stored.SKU == product.SKU && stored.ID != product.ID {
+			return fmt.Errorf("product with sku %q: %w", product.SKU, storage.ErrConflict)
+		}
+	}
+	return nil
+}
+
+// findVariant returns the stored variant of the product with the given ID.
+func (mock *MockProductStore) findVariant(productID service.ProductID, id service.VariantID) (*service.Variant, error) {
+	variant, exists := mock.Variants[id]
+	if !exists || variant.ProductID != productID {
+		return nil, fmt.Errorf("variant with id %d of product with id %d: %w", id, productID, storage.ErrNotFound)
+	}
+	return variant, nil
+}
+
+// checkSKU rejects a variant whose SKU belongs to another variant, like the variants unique key.
+func (mock *MockProductStore) checkSKU(variant *service.Variant) error {
+	for _, stored := range mock.Variants {
+		if stored.SKU == variant.SKU && stored.ID != variant.ID {
+			return fmt.Errorf("variant with sku %q: %w", variant.SKU, storage.ErrConflict)
+		}
+	}
+	return nil
+}
+
+// compareScheduledPrices orders scheduled prices by effective time, ties being broken by ID.
+func compareScheduledPrices(a, b *service.ScheduledPrice) int {
+	return cmp.Or(a.EffectiveAt.Compare(b.EffectiveAt), cmp.Compare(a.ID, b.ID))
+}
+
+// copyProduct returns a copy of the product, without any pending quantity delta and with normalized tags, like the stored ones.
+func copyProduct(product *service.Product) *service.Product {
+	return &service.Product{
+		Price:       product.Price,
+		CreatedAt:   product.CreatedAt,
+		LastUpdated: product.LastUpdated,
+		ID:          product.ID,
+		SKU:         product.SKU,
+		Version:     product.Version,
+		Quantity:    product.Quantity,
+		Discount:    product.Discount,
+		Name:        product.Name,
+		Description: product.Description,
+		Tags:        service.NormalizeTags(product.Tags),
+		Attributes:  maps.Clone(product.Attributes),
+	}
+}
+
+// copyCategory returns a copy of the category that shares no attribute schema with the original.
+func copyCategory(category *service.Category) *service.Category {
+	copied := *category
+	copied.AttributeSchema = maps.Clone(category.AttributeSchema)
+	return &copied
+}
+
+// copyVariant returns a copy of the variant that shares no price with the original.
+func copyVariant(variant *service.Variant) *service.Variant {
+	copied := *variant
+	if variant.Price != nil {
+		price := *variant.Price
+		copied.Price = &price
+	}
+	return &copied
+}
+
+// checkVersion compares the stored version with the expected one, where zero expects any version.
+func checkVersion(stored *service.Product, version int64) error {
+	if version != 0 && version != stored.Version {
+		return fmt.Errorf("product with id %d: %w: expected %d, stored %d", stored.ID, storage.ErrVersionMismatch, version, stored.Version)
+	}
+	return nil
+}
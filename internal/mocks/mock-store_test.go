@@ -0,0 +1,13 @@
+package mocks
+
+import (
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/storage/storagetest"
+	"testing"
+)
+
+func TestMockProductStore(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.ProductStore {
+		return NewMockProductStore()
+	})
+}
@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"cmp"
+	"math"
+	"ntsiris/product-microservice/internal/service"
+	"slices"
+)
+
+// Weights of the term occurrences in each product field, favouring name matches like the SQL stores do.
+const (
+	nameWeight        = 10
+	descriptionWeight = 1
+)
+
+// searchIndex is an in-process inverted index over the name and description of products.
+// It mirrors the full-text indexes of the SQL stores: products match any of the search terms and are
+// scored by the weighted frequency of the terms, each scaled by how rare the term is across products.
+type searchIndex struct {
+	postings map[string]map[service.ProductID]float64 // postings maps every term to the weighted frequency of the term in each product containing it.
+	products map[service.ProductID]*service.Product   // products holds the indexed products by ID.
+}
+
+// newSearchIndex creates a searchIndex over the specified products.
+func newSearchIndex(products map[int64]*service.Product) *searchIndex {
+	index := &searchIndex{
+		postings: make(map[string]map[service.ProductID]float64),
+		products: make(map[service.ProductID]*service.Product),
+	}
+
+	for _, product := range products {
+		index.add(product)
+	}
+	return index
+}
+
+// add indexes the terms of the product's name and description.
+func (index *searchIndex) add(product *service.Product) {
+	index.products[product.ID] = product
+
+	for _, field := range []struct {
+		text   string
+		weight float64
+	}{
+		{product.Name, nameWeight},
+		{product.Description, descriptionWeight},
+	} {
+		for _, term := range service.Tokenize(field.text) {
+			if index.postings[term] == nil {
+				index.postings[term] = make(map[service.ProductID]float64)
+			}
+			index.postings[term][product.ID] += field.weight
+		}
+	}
+}
+
+// search returns the products matching any of the terms, the most relevant first, ties being broken by ID.
+func (index *searchIndex) search(terms []string) []*service.SearchHit {
+	scores := make(map[service.ProductID]float64)
+	for _, term := range terms {
+		postings := index.postings[term]
+		rarity := math.Log(1 + float64(len(index.products))/float64(max(len(postings), 1)))
+
+		for id, frequency := range postings {
+			scores[id] += frequency * rarity
+		}
+	}
+
+	var hits []*service.SearchHit
+	for id, score := range scores {
+		hits = append(hits, &service.SearchHit{Product: index.products[id], Score: score})
+	}
+	slices.SortFunc(hits, func(a, b *service.SearchHit) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+	return hits
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// MaxAttributeNameLength is the maximum number of characters of an attribute name.
+const MaxAttributeNameLength = 64
+
+// Attributes are the custom, schema-less attributes of a product (e.g., weight, voltage or material), keyed by name.
+// Values are strings, numbers or booleans, stored as a JSON object through the sql.Scanner and driver.Valuer implementations.
+type Attributes map[string]any
+
+// AttributeType names the type of the values an attribute schema accepts.
+type AttributeType string
+
+// The types of attribute values.
+const (
+	AttributeString  AttributeType = "string"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+)
+
+// AttributeDefinition describes an attribute of the products of a category.
+type AttributeDefinition struct {
+	Type     AttributeType `json:"type" validate:"required,oneof=string number boolean"`
+	Required bool          `json:"required"` // Required rejects products of the category lacking the attribute.
+}
+
+// AttributeSchema defines the attributes of the products of a category, keyed by name.
+// Attributes missing from the schema are accepted with any value.
+type AttributeSchema map[string]AttributeDefinition
+
+// AttributeError describes an attribute of a product that does not conform to the schema of one of its categories.
+type AttributeError struct {
+	Name string `json:"name"` // Name is the name of the attribute.
+	Tag  string `json:"tag"`  // Tag is the violated rule, "required" for missing attributes or "type" for values of another type.
+	Type string `json:"type"` // Type is the type of the attribute, as defined by the schema.
+}
+
+// IsValidAttributeName reports whether the name has between one and MaxAttributeNameLength characters,
+// each being an ASCII letter, a digit, an underscore or a hyphen.
+//
+// Parameters:
+// - name: The attribute name to check.
+//
+// Returns:
+// - True if the name is valid; otherwise, false.
+func IsValidAttributeName(name string) bool {
+	if name == "" || len(name) > MaxAttributeNameLength {
+		return false
+	}
+
+	for _, char := range name {
+		if !('a' <= char && char <= 'z' || 'A' <= char && char <= 'Z' || '0' <= char && char <= '9' || char == '_' || char == '-') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsValidAttributeValue reports whether the value is a string, a number or a boolean, as decoded from JSON.
+//
+// Parameters:
+// - value: The attribute value to check.
+//
+// Returns:
+// - True if the value is valid; otherwise, false.
+func IsValidAttributeValue(value any) bool {
+	return attributeType(value) != ""
+}
+
+// AttributeText returns the textual form attribute filters compare values against: strings as they are,
+// numbers in their shortest decimal form and booleans as "true" or "false".
+//
+// Parameters:
+// - value: The attribute value.
+//
+// Returns:
+// - The text of the value.
+func AttributeText(value any) string {
+	switch value := value.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// CheckAttributes checks the attributes of a product against the schemas of its categories.
+//
+// Parameters:
+// - attributes: The attributes of the product.
+// - categories: The categories of the product, whose schemas apply.
+//
+// Returns:
+// - The distinct violations of the schemas, ordered by category and attribute name; nil if the attributes conform to every schema.
+func CheckAttributes(attributes Attributes, categories []*Category) []AttributeError {
+	var attributeErrs []AttributeError
+
+	for _, category := range categories {
+		names := make([]string, 0, len(category.AttributeSchema))
+		for name := range category.AttributeSchema {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			definition := category.AttributeSchema[name]
+			attributeErr := AttributeError{Name: name, Type: string(definition.Type)}
+
+			value, found := attributes[name]
+			switch {
+			case !found && definition.Required:
+				attributeErr.Tag = "required"
+			case found && attributeType(value) != definition.Type:
+				attributeErr.Tag = "type"
+			default:
+				continue
+			}
+
+			if !slices.Contains(attributeErrs, attributeErr) {
+				attributeErrs = append(attributeErrs, attributeErr)
+			}
+		}
+	}
+
+	return attributeErrs
+}
+
+// matchesAttributes reports whether the product has every attribute of the filter, with the same textual value.
+func matchesAttributes(product *Product, attributes map[string]string) bool {
+	for name, text := range attributes {
+		value, found := product.Attributes[name]
+		if !found || AttributeText(value) != text {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cloneAttributes returns a copy of the attributes, empty but not nil if there are none.
+func cloneAttributes(attributes Attributes) Attributes {
+	clone := make(Attributes, len(attributes))
+	for name, value := range attributes {
+		clone[name] = value
+	}
+
+	return clone
+}
+
+// attributeType returns the type of an attribute value, or an empty string if the value is not a string, number or boolean.
+func attributeType(value any) AttributeType {
+	switch value.(type) {
+	case string:
+		return AttributeString
+	case float64:
+		return AttributeNumber
+	case bool:
+		return AttributeBoolean
+	default:
+		return ""
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding nil attributes as an empty object.
+func (attributes Attributes) MarshalJSON() ([]byte, error) {
+	if attributes == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[string]any(attributes))
+}
+
+// Value implements the driver.Valuer interface, passing the attributes to the database as a JSON object.
+func (attributes Attributes) Value() (driver.Value, error) {
+	return jsonValue(attributes)
+}
+
+// Scan implements the sql.Scanner interface, reading the attributes from a JSON column, empty if NULL.
+func (attributes *Attributes) Scan(src any) error {
+	*attributes = Attributes{}
+	return scanJSON(src, attributes)
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding nil schemas as an empty object.
+func (schema AttributeSchema) MarshalJSON() ([]byte, error) {
+	if schema == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(map[string]AttributeDefinition(schema))
+}
+
+// Value implements the driver.Valuer interface, passing the schema to the database as a JSON object.
+func (schema AttributeSchema) Value() (driver.Value, error) {
+	return jsonValue(schema)
+}
+
+// Scan implements the sql.Scanner interface, reading the schema from a JSON column, empty if NULL.
+func (schema *AttributeSchema) Scan(src any) error {
+	*schema = AttributeSchema{}
+	return scanJSON(src, schema)
+}
+
+// jsonValue encodes a value to be stored in a JSON column.
+// The JSON is passed as a string, which every database converts to its JSON type.
+func jsonValue(value json.Marshaler) (driver.Value, error) {
+	encoded, err := value.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// scanJSON decodes a JSON column into the destination, leaving it unchanged if NULL.
+func scanJSON(src any, destination any) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, destination)
+	case string:
+		return json.Unmarshal([]byte(src), destination)
+	default:
+		return fmt.Errorf("error: cannot scan %T into a JSON object", src)
+	}
+}
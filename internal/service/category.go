@@ -0,0 +1,179 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+// CategoryID is a unique identifier type for categories.
+type CategoryID int64
+
+// Category is a node of the category tree products are classified in.
+type Category struct {
+	ID              CategoryID      `json:"id"`
+	Name            string          `json:"name"`
+	ParentID        *CategoryID     `json:"parentId"`                  // ParentID is the ID of the parent category, nil for root categories.
+	AttributeSchema AttributeSchema `json:"attributeSchema,omitempty"` // AttributeSchema defines the attributes of the products linked to the category, if any.
+	CreatedAt       time.Time       `json:"createdAt"`
+	LastUpdated     time.Time       `json:"lastUpdated"`
+}
+
+// CategoryNode is a category along with its subcategories, as listed in the category tree.
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"` // Children are the subcategories, ordered by name.
+}
+
+// CategoryPayload represents the data used to create a category or replace its details.
+type CategoryPayload struct {
+	Name            string          `json:"name" validate:"required"`
+	ParentID        *CategoryID     `json:"parentId" validate:"omitempty,gt=0"`                              // ParentID is the ID of the parent category, nil for a root category.
+	AttributeSchema AttributeSchema `json:"attributeSchema" validate:"dive,keys,attribute,endkeys,required"` // AttributeSchema, if empty, accepts any attributes.
+}
+
+// ProductCategoriesPayload represents the categories a product is linked to.
+type ProductCategoriesPayload struct {
+	CategoryIDs []CategoryID `json:"categoryIds" validate:"required,dive,gt=0"`
+}
+
+// CategoryCRUDer defines an interface for CRUD operations on the categories of the category tree.
+type CategoryCRUDer interface {
+	// CreateCategory adds a new category to the store.
+	// The Category parameter may be modified with additional information (e.g., ID).
+	CreateCategory(context.Context, *Category) error
+
+	// RetrieveCategory fetches a category by its unique ID.
+	RetrieveCategory(context.Context, CategoryID) (*Category, error)
+
+	// RetrieveCategories lists every category, ordered by name and ID.
+	RetrieveCategories(context.Context) ([]*Category, error)
+
+	// UpdateCategory replaces the name, parent and attribute schema of an existing category, rejecting parents
+	// that would make the category its own ancestor and schemas the attributes of its linked products do not conform to.
+	// The Category parameter may be modified with additional information.
+	UpdateCategory(context.Context, *Category) error
+
+	// DeleteCategory removes a category without subcategories, unlinking it from its products.
+	DeleteCategory(context.Context, CategoryID) error
+}
+
+// ProductCategorizer defines an interface for linking products to categories, each product to any number of them.
+type ProductCategorizer interface {
+	// SetProductCategories replaces the categories the product with the given ID is linked to,
+	// rejecting categories whose attribute schemas the attributes of the product do not conform to.
+	SetProductCategories(context.Context, ProductID, []CategoryID) error
+
+	// RetrieveProductCategories lists the categories the product with the given ID is linked to, ordered by name and ID.
+	RetrieveProductCategories(context.Context, ProductID) ([]*Category, error)
+}
+
+// NewCategory creates a new Category instance based on the provided CategoryPayload.
+//
+// Parameters:
+// - payload: The payload containing category creation details.
+//
+// Returns:
+// - A pointer to the newly created Category instance.
+func NewCategory(payload *CategoryPayload) *Category {
+	return &Category{
+		Name:            payload.Name,
+		ParentID:        payload.ParentID,
+		AttributeSchema: payload.AttributeSchema,
+		CreatedAt:       time.Now().UTC(),
+		LastUpdated:     time.Now().UTC(),
+	}
+}
+
+// UpdateCategory replaces the name, parent and attribute schema of the category with those of the CategoryPayload.
+//
+// Parameters:
+// - category: The category to update.
+// - payload: The payload containing the new category details.
+func UpdateCategory(category *Category, payload *CategoryPayload) {
+	category.Name = payload.Name
+	category.ParentID = payload.ParentID
+	category.AttributeSchema = payload.AttributeSchema
+	category.LastUpdated = time.Now().UTC()
+}
+
+// CompareCategories orders categories by name, ties being broken by ID, the way the stores list them.
+//
+// Parameters:
+// - a, b: The compared categories.
+//
+// Returns:
+// - A negative number if a precedes b, a positive number if b precedes a, or zero if they are the same category.
+func CompareCategories(a, b *Category) int {
+	return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+}
+
+// BuildCategoryTree arranges categories into trees, following their parents.
+// Categories whose parent is not among the categories are returned as roots.
+//
+// Parameters:
+// - categories: The categories to arrange.
+//
+// Returns:
+// - The root categories along with their descendants, every level ordered by name.
+func BuildCategoryTree(categories []*Category) []*CategoryNode {
+	nodes := make(map[CategoryID]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{Category: category, Children: []*CategoryNode{}}
+	}
+
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, found := nodes[*category.ParentID]; found {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+
+		roots = append(roots, node)
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+// DescendantIDs returns the ID of a category followed by the IDs of all of its descendants, closest first.
+//
+// Parameters:
+// - categories: Every category of the tree.
+// - id: The ID of the category whose descendants are collected.
+//
+// Returns:
+// - The IDs of the category and its descendants; just the ID if the category has no descendants or is unknown.
+func DescendantIDs(categories []*Category, id CategoryID) []CategoryID {
+	children := make(map[CategoryID][]CategoryID)
+	for _, category := range categories {
+		if category.ParentID != nil {
+			children[*category.ParentID] = append(children[*category.ParentID], category.ID)
+		}
+	}
+
+	ids := []CategoryID{id}
+	for next := 0; next < len(ids); next++ {
+		for _, child := range children[ids[next]] {
+			// Guard against cycles, which the stores never persist.
+			if !slices.Contains(ids, child) {
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids
+}
+
+// sortNodes orders the nodes, and recursively their children, by name.
+func sortNodes(nodes []*CategoryNode) {
+	slices.SortFunc(nodes, func(a, b *CategoryNode) int { return CompareCategories(a.Category, b.Category) })
+
+	for _, node := range nodes {
+		sortNodes(node.Children)
+	}
+}
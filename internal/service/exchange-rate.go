@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// RateDigits is the number of fractional digits exchange rates are held with,
+// matching the scale of the DECIMAL(18,6) rate column.
+const RateDigits = 6
+
+// Rate is an exact exchange rate, held as an integer number of millionths (e.g., 1085000 for 1.085).
+// Like Money, it is encoded in JSON as a decimal string, while decimal numbers are accepted too,
+// and stored in a DECIMAL column.
+type Rate int64
+
+// ExchangeRate is the rate converting amounts from a base currency to a quote currency.
+// The rate also converts amounts from the quote currency back to the base currency, by division.
+type ExchangeRate struct {
+	Base      Currency  `json:"base"`      // Base is the currency converted from.
+	Quote     Currency  `json:"quote"`     // Quote is the currency converted to.
+	Rate      Rate      `json:"rate"`      // Rate is the amount of quote currency one unit of base currency is worth.
+	UpdatedAt time.Time `json:"updatedAt"` // UpdatedAt is the time the rate was last set.
+}
+
+// ExchangeRatePayload represents the data used to set an exchange rate.
+type ExchangeRatePayload struct {
+	Rate Rate `json:"rate" validate:"required,gt=0"`
+}
+
+// ExchangeRateManager defines an interface for managing the exchange rates between currencies.
+type ExchangeRateManager interface {
+	// SaveExchangeRate creates or replaces the rate from the ExchangeRate's base to its quote currency.
+	SaveExchangeRate(context.Context, *ExchangeRate) error
+
+	// RetrieveExchangeRate fetches the rate between two currencies, whether stored from the first currency
+	// to the second or from the second to the first.
+	RetrieveExchangeRate(context.Context, Currency, Currency) (*ExchangeRate, error)
+
+	// RetrieveExchangeRates lists every stored rate, ordered by base and quote currency.
+	RetrieveExchangeRates(context.Context) ([]*ExchangeRate, error)
+
+	// DeleteExchangeRate removes the rate from the first currency to the second.
+	DeleteExchangeRate(context.Context, Currency, Currency) error
+}
+
+// PricedProduct is a product whose prices are expressed in a currency other than its base currency.
+type PricedProduct struct {
+	*Product
+	ExchangeRate *ExchangeRate // ExchangeRate is the rate the prices were converted with, nil if not converted.
+}
+
+// IsSupportedCurrency reports whether the currency is one of SupportedCurrencies.
+func IsSupportedCurrency(currency Currency) bool {
+	return slices.Contains(SupportedCurrencies, currency)
+}
+
+// Convert converts an amount of either currency of the exchange rate into the other one,
+// rounding the result to the nearest minor unit, halves away from zero.
+//
+// Parameters:
+// - money: The amount to convert, in the base or the quote currency of the rate.
+//
+// Returns:
+// - The converted amount and nil if successful.
+// - An error if the amount is in neither currency of the rate or the rate is not positive.
+func (exchangeRate *ExchangeRate) Convert(money Money) (Money, error) {
+	if exchangeRate.Rate <= 0 {
+		return Money{}, fmt.Errorf("error: invalid exchange rate %s", exchangeRate.Rate)
+	}
+
+	amount := big.NewInt(money.Amount)
+	rate := big.NewInt(int64(exchangeRate.Rate))
+	scale := big.NewInt(int64(math.Pow10(RateDigits)))
+
+	switch money.currency() {
+	case exchangeRate.Base:
+		return Money{Amount: divideRounded(amount.Mul(amount, rate), scale), Currency: exchangeRate.Quote}, nil
+	case exchangeRate.Quote:
+		return Money{Amount: divideRounded(amount.Mul(amount, scale), rate), Currency: exchangeRate.Base}, nil
+	}
+
+	return Money{}, fmt.Errorf("error: cannot convert %s with the %s/%s exchange rate", money.currency(), exchangeRate.Base, exchangeRate.Quote)
+}
+
+// PriceIn expresses the product's prices in the specified currency.
+//
+// Parameters:
+// - product: The product to price.
+// - currency: The currency of the prices.
+// - exchangeRate: The rate between the product's currency and the requested one, or nil if they are the same.
+//
+// Returns:
+// - A pointer to the PricedProduct, holding a copy of the product with converted prices, and nil if successful.
+// - An error if the exchange rate does not convert between the two currencies.
+func PriceIn(product *Product, currency Currency, exchangeRate *ExchangeRate) (*PricedProduct, error) {
+	if product.Price.currency() == currency {
+		return &PricedProduct{Product: product}, nil
+	}
+	if exchangeRate == nil {
+		return nil, fmt.Errorf("error: no exchange rate from %s to %s", product.Price.currency(), currency)
+	}
+
+	price, err := exchangeRate.Convert(product.Price)
+	if err != nil {
+		return nil, err
+	}
+	if price.Currency != currency {
+		return nil, fmt.Errorf("error: the %s/%s exchange rate does not convert to %s", exchangeRate.Base, exchangeRate.Quote, currency)
+	}
+
+	converted := *product
+	converted.Price = price
+	return &PricedProduct{Product: &converted, ExchangeRate: exchangeRate}, nil
+}
+
+// String formats the rate as a decimal number with RateDigits fractional digits (e.g., "1.085000").
+func (rate Rate) String() string {
+	return formatDecimal(int64(rate), RateDigits)
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the rate as a decimal string.
+func (rate Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rate.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding a decimal string or number.
+// A null value leaves the rate unchanged.
+func (rate *Rate) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text, err := decimalText(data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseDecimal(text, RateDigits)
+	if err != nil {
+		return err
+	}
+
+	*rate = Rate(parsed)
+	return nil
+}
+
+// Value implements the driver.Valuer interface, passing the rate to the database as an exact decimal string.
+func (rate Rate) Value() (driver.Value, error) {
+	return rate.String(), nil
+}
+
+// Scan implements the sql.Scanner interface, reading a rate from a DECIMAL column.
+func (rate *Rate) Scan(src any) error {
+	scanned, err := scanDecimal(src, RateDigits)
+	if err != nil {
+		return err
+	}
+
+	*rate = Rate(scanned)
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the product, along with its final price,
+// and the exchange rate its prices were converted with, if any.
+func (pricedProduct PricedProduct) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		productJSON
+		ExchangeRate *ExchangeRate `json:"exchangeRate,omitempty"`
+	}{newProductJSON(pricedProduct.Product), pricedProduct.ExchangeRate})
+}
+
+// divideRounded divides two integers, rounding the quotient to the nearest integer, halves away from zero.
+func divideRounded(dividend, divisor *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(dividend, divisor, new(big.Int))
+
+	// Round away from zero if the remainder is at least half of the divisor.
+	if new(big.Int).Abs(new(big.Int).Mul(remainder, big.NewInt(2))).Cmp(new(big.Int).Abs(divisor)) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(dividend.Sign()*divisor.Sign())))
+	}
+
+	return quotient.Int64()
+}
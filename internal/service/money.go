@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code (e.g., "EUR").
+type Currency string
+
+// DefaultCurrency is the currency of amounts whose currency is not specified.
+const DefaultCurrency Currency = "EUR"
+
+// SupportedCurrencies lists the currencies products can be priced in and converted to.
+var SupportedCurrencies = []Currency{"EUR", "USD", "GBP"}
+
+// MinorUnitDigits is the number of decimal digits of the minor unit of every supported currency,
+// matching the scale of the DECIMAL(10,2) price columns.
+const MinorUnitDigits = 2
+
+// MaxAmount is the largest amount, in minor units, the DECIMAL(10,2) price columns hold (99999999.99).
+const MaxAmount = 9_999_999_999
+
+// Money is an exact amount of money, held in integer minor units (e.g., cents) of its currency,
+// so that prices and totals never drift through floating point rounding.
+// It is encoded in JSON as a decimal string (e.g., "19.99"), while decimal numbers are accepted too,
+// and stored in DECIMAL columns through its sql.Scanner and driver.Valuer implementations.
+type Money struct {
+	Amount   int64    // Amount is the number of minor units (e.g., cents).
+	Currency Currency // Currency is the currency of the amount, DefaultCurrency if not specified.
+}
+
+// ParseMoney parses a decimal amount (e.g., "19.99", "-5" or "0.5") into Money of the specified currency.
+//
+// Parameters:
+// - text: The decimal amount, with at most MinorUnitDigits fractional digits.
+// - currency: The currency of the amount.
+//
+// Returns:
+// - The parsed Money and nil if the text is a valid amount.
+// - An error if the text is not a decimal number, has too many fractional digits or overflows.
+func ParseMoney(text string, currency Currency) (Money, error) {
+	amount, err := parseDecimal(text, MinorUnitDigits)
+	if err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// String formats the amount as a decimal number with MinorUnitDigits fractional digits (e.g., "19.99"),
+// without its currency.
+//
+// Returns:
+// - The formatted amount.
+func (money Money) String() string {
+	return formatDecimal(money.Amount, MinorUnitDigits)
+}
+
+// Add returns the sum of two amounts of the same currency.
+//
+// Parameters:
+// - other: The amount added.
+//
+// Returns:
+// - The sum and nil if both amounts share the currency.
+// - An error if the currencies differ.
+func (money Money) Add(other Money) (Money, error) {
+	if err := money.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: money.Amount + other.Amount, Currency: money.currency()}, nil
+}
+
+// Sub returns the difference of two amounts of the same currency.
+//
+// Parameters:
+// - other: The amount subtracted.
+//
+// Returns:
+// - The difference and nil if both amounts share the currency.
+// - An error if the currencies differ.
+func (money Money) Sub(other Money) (Money, error) {
+	if err := money.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+
+	return Money{Amount: money.Amount - other.Amount, Currency: money.currency()}, nil
+}
+
+// Mul returns the amount multiplied by an integer factor (e.g., the price of a number of units).
+//
+// Parameters:
+// - factor: The multiplier.
+//
+// Returns:
+// - The multiplied amount, in the same currency.
+func (money Money) Mul(factor int64) Money {
+	return Money{Amount: money.Amount * factor, Currency: money.currency()}
+}
+
+// Compare compares two amounts regardless of their currency, the way the stores order and filter prices,
+// each product's price being expressed in its own currency.
+//
+// Parameters:
+// - other: The amount compared against.
+//
+// Returns:
+// - A negative number if money is less than other, a positive number if greater, or zero if they are equal.
+func (money Money) Compare(other Money) int {
+	return cmp.Compare(money.Amount, other.Amount)
+}
+
+// IsStorable reports whether the amount fits the DECIMAL(10,2) price columns, within MaxAmount either side of zero.
+func (money Money) IsStorable() bool {
+	return abs(money.Amount) <= MaxAmount
+}
+
+// IsNegative reports whether the amount is less than zero.
+func (money Money) IsNegative() bool {
+	return money.Amount < 0
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the amount as a decimal string.
+func (money Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(money.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, decoding a decimal string or number.
+// The currency is kept if already set, or DefaultCurrency otherwise. A null value leaves the amount unchanged.
+func (money *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text, err := decimalText(data)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(text, money.currency())
+	if err != nil {
+		return err
+	}
+
+	*money = parsed
+	return nil
+}
+
+// Value implements the driver.Valuer interface, passing the amount to the database as an exact decimal string.
+func (money Money) Value() (driver.Value, error) {
+	return money.String(), nil
+}
+
+// Scan implements the sql.Scanner interface, reading an amount from a DECIMAL column.
+// The currency is kept if already set, or DefaultCurrency otherwise.
+func (money *Money) Scan(src any) error {
+	var err error
+	scanned := Money{Currency: money.currency()}
+
+	if scanned.Amount, err = scanDecimal(src, MinorUnitDigits); err != nil {
+		return err
+	}
+
+	*money = scanned
+	return nil
+}
+
+// currency returns the currency of the amount, or DefaultCurrency if not specified.
+func (money Money) currency() Currency {
+	if money.Currency == "" {
+		return DefaultCurrency
+	}
+
+	return money.Currency
+}
+
+// checkCurrency returns an error if the amounts are of different currencies.
+func (money Money) checkCurrency(other Money) error {
+	if money.currency() != other.currency() {
+		return fmt.Errorf("error: currency mismatch: %s and %s", money.currency(), other.currency())
+	}
+
+	return nil
+}
+
+// abs returns the absolute value of an integer.
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+// parseDecimal parses a decimal number (e.g., "19.99") into an integer scaled by 10^digits (e.g., 1999 for 2 digits).
+func parseDecimal(text string, digits int) (int64, error) {
+	unsigned, negative := strings.CutPrefix(text, "-")
+	units, fraction, _ := strings.Cut(unsigned, ".")
+
+	if units == "" || strings.TrimLeft(units, "0123456789") != "" || strings.TrimLeft(fraction, "0123456789") != "" {
+		return 0, fmt.Errorf("error: invalid decimal %q", text)
+	}
+	if len(fraction) > digits {
+		return 0, fmt.Errorf("error: decimal %q has more than %d fractional digits", text, digits)
+	}
+
+	value, err := strconv.ParseInt(units+fraction+strings.Repeat("0", digits-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error: invalid decimal %q: %v", text, err)
+	}
+	if negative {
+		value = -value
+	}
+
+	return value, nil
+}
+
+// formatDecimal formats an integer scaled by 10^digits as a decimal number with the given fractional digits.
+func formatDecimal(value int64, digits int) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+
+	scale := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs(value/scale), digits, abs(value%scale))
+}
+
+// decimalText returns the text of a decimal JSON value, given either as a string or as a number.
+func decimalText(data []byte) (string, error) {
+	text := string(data)
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return "", err
+		}
+	}
+
+	return text, nil
+}
+
+// scanDecimal reads a DECIMAL column value into an integer scaled by 10^digits.
+// Drivers return DECIMAL values as decimal strings, except SQLite, which may return integers or floats.
+func scanDecimal(src any, digits int) (int64, error) {
+	scale := math.Pow10(digits)
+
+	switch value := src.(type) {
+	case []byte:
+		return parseDecimal(string(value), digits)
+	case string:
+		return parseDecimal(value, digits)
+	case int64:
+		return value * int64(scale), nil
+	case float64:
+		return int64(math.Round(value * scale)), nil
+	}
+
+	return 0, fmt.Errorf("error: cannot scan %T into a decimal", src)
+}
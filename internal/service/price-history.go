@@ -0,0 +1,133 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// ScheduledPriceID is a unique identifier type for scheduled price changes.
+type ScheduledPriceID int64
+
+// PriceChange records a change of a product's price, kept in the product's price history.
+type PriceChange struct {
+	ProductID     ProductID // ProductID is the ID of the product whose price changed.
+	PreviousPrice Money     // PreviousPrice is the price replaced by the change.
+	Price         Money     // Price is the price set by the change.
+	ChangedAt     time.Time // ChangedAt is the time the change was applied.
+}
+
+// ScheduledPrice is a price change that takes effect at a future time, applied by RunPriceScheduler.
+type ScheduledPrice struct {
+	ID          ScheduledPriceID `json:"id"`
+	ProductID   ProductID        `json:"productId"`
+	Price       Money            `json:"price"`       // Price is the price set once the change takes effect.
+	EffectiveAt time.Time        `json:"effectiveAt"` // EffectiveAt is the time from which the price applies.
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+// ScheduledPricePayload represents the data used to schedule a price change.
+type ScheduledPricePayload struct {
+	Price       Money     `json:"price" validate:"required,gt=0,money"`
+	Currency    Currency  `json:"currency" validate:"omitempty,currency"` // Currency is the currency of the price, the product's currency if empty.
+	EffectiveAt time.Time `json:"effectiveAt" validate:"required,gt"`     // EffectiveAt must lie in the future.
+}
+
+// PriceHistorian defines an interface for reading the recorded price changes of products.
+// Stores record a PriceChange whenever a product's price changes, in the same transaction as the change.
+type PriceHistorian interface {
+	// RetrievePriceHistory lists the price changes of the product with the given ID, the most recent first.
+	RetrievePriceHistory(context.Context, ProductID) ([]*PriceChange, error)
+}
+
+// PriceScheduler defines an interface for scheduling price changes and applying them once they are due.
+type PriceScheduler interface {
+	// SchedulePrice stores a price change taking effect at the ScheduledPrice's EffectiveAt.
+	// The ScheduledPrice parameter may be modified with additional information (e.g., ID).
+	SchedulePrice(context.Context, *ScheduledPrice) error
+
+	// RetrieveScheduledPrices lists the pending price changes of the product with the given ID, the earliest first.
+	RetrieveScheduledPrices(context.Context, ProductID) ([]*ScheduledPrice, error)
+
+	// CancelScheduledPrice removes a pending price change of the product with the given ID.
+	CancelScheduledPrice(context.Context, ProductID, ScheduledPriceID) error
+
+	// ApplyDuePrices applies, in order, every pending price change effective at or before the given time,
+	// recording each in the price history, and returns the number of changes applied.
+	ApplyDuePrices(context.Context, time.Time) (int, error)
+}
+
+// NewScheduledPrice creates a new ScheduledPrice for the product based on the provided ScheduledPricePayload.
+//
+// Parameters:
+// - product: The product whose price is scheduled to change.
+// - payload: The payload containing the scheduled price details.
+//
+// Returns:
+// - A pointer to the newly created ScheduledPrice instance.
+func NewScheduledPrice(product *Product, payload *ScheduledPricePayload) *ScheduledPrice {
+	return &ScheduledPrice{
+		ProductID:   product.ID,
+		Price:       Money{Amount: payload.Price.Amount, Currency: cmp.Or(payload.Currency, product.Price.currency())},
+		EffectiveAt: payload.EffectiveAt.UTC(),
+		CreatedAt:   time.Now().UTC(),
+	}
+}
+
+// RunPriceScheduler applies the due scheduled price changes every interval, until the context is done.
+// Failures are logged and retried on the following run, so a temporarily unavailable store delays changes
+// without losing them. A non-positive interval disables the scheduler.
+//
+// Parameters:
+// - ctx: The context whose cancellation stops the scheduler and aborts any run in progress.
+// - scheduler: The store holding the scheduled price changes.
+// - interval: The time between two runs, bounding how late a change takes effect.
+func RunPriceScheduler(ctx context.Context, scheduler PriceScheduler, interval time.Duration) {
+	if interval <= 0 {
+		log.Print("Price scheduler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := scheduler.ApplyDuePrices(ctx, time.Now().UTC())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Apply scheduled prices %v", err)
+		}
+		if applied > 0 {
+			log.Printf("Applied %d scheduled price changes", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the prices along with their currencies.
+func (change PriceChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ProductID        ProductID `json:"productId"`
+		PreviousPrice    Money     `json:"previousPrice"`
+		PreviousCurrency Currency  `json:"previousCurrency"`
+		Price            Money     `json:"price"`
+		Currency         Currency  `json:"currency"`
+		ChangedAt        time.Time `json:"changedAt"`
+	}{change.ProductID, change.PreviousPrice, change.PreviousPrice.currency(), change.Price, change.Price.currency(), change.ChangedAt})
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the scheduled price along with its currency.
+func (scheduledPrice ScheduledPrice) MarshalJSON() ([]byte, error) {
+	type scheduledPriceFields ScheduledPrice
+
+	return json.Marshal(struct {
+		scheduledPriceFields
+		Currency Currency `json:"currency"`
+	}{scheduledPriceFields(scheduledPrice), scheduledPrice.Price.currency()})
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// MaxDiscount is the highest discount percentage, which makes a product free.
+const MaxDiscount = 100
+
+// basisPointsPerPercent is the number of basis points (hundredths of a percent) in a percent.
+const basisPointsPerPercent = 100
+
+// maxDiscountBasisPoints is MaxDiscount in basis points.
+const maxDiscountBasisPoints = MaxDiscount * basisPointsPerPercent
+
+// Discounted returns the amount reduced by a percentage, rounding the deducted amount to the nearest minor unit,
+// halves rounded up in the buyer's favour. Percentages are applied with a precision of hundredths of a percent
+// and clamped to [0, MaxDiscount], so the result never exceeds the amount nor drops below zero.
+//
+// Parameters:
+// - percent: The discount percentage (e.g., 12.5 for 12.5%).
+//
+// Returns:
+// - The discounted amount, in the same currency.
+func (money Money) Discounted(percent float32) Money {
+	basisPoints := int64(math.Round(float64(percent) * basisPointsPerPercent))
+	basisPoints = min(max(basisPoints, 0), maxDiscountBasisPoints)
+
+	deducted := (abs(money.Amount)*basisPoints + maxDiscountBasisPoints/2) / maxDiscountBasisPoints
+	if money.IsNegative() {
+		deducted = -deducted
+	}
+
+	return Money{Amount: money.Amount - deducted, Currency: money.currency()}
+}
+
+// FinalPrice returns the price the product is sold at, that is, its price reduced by its discount percentage.
+//
+// Returns:
+// - The discounted price, rounded as described by Money.Discounted.
+func (product *Product) FinalPrice() Money {
+	return product.Price.Discounted(product.Discount)
+}
+
+// productFields has the fields of Product without its methods, so it is encoded by the default JSON encoding.
+type productFields Product
+
+// productJSON is the JSON representation of a Product, extended with the fields computed by the service layer.
+type productJSON struct {
+	productFields
+	Currency   Currency `json:"currency"`   // Currency is the currency of the prices.
+	FinalPrice Money    `json:"finalPrice"` // FinalPrice is the price after the discount, as returned by Product.FinalPrice.
+}
+
+// newProductJSON creates the JSON representation of the product.
+func newProductJSON(product *Product) productJSON {
+	return productJSON{productFields: productFields(*product), Currency: product.Price.currency(), FinalPrice: product.FinalPrice()}
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the product along with its final price.
+func (product Product) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newProductJSON(&product))
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the matching product, along with its
+// final price, and the relevance of the hit.
+func (hit SearchHit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		productJSON
+		Score float64 `json:"score"`
+	}{newProductJSON(hit.Product), hit.Score})
+}
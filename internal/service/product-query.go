@@ -0,0 +1,325 @@
+package service
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultPageLimit is the number of products listed per page when no valid limit is requested.
+const DefaultPageLimit = 10
+
+// MaxPageLimit is the largest number of products a single page may list; larger limits are rejected.
+const MaxPageLimit = 100
+
+// ProductQuery describes which products a listing returns and in which order.
+// Products are paged either by page number (offset pagination) or, when After is set,
+// by continuing after the position marked by the cursor (keyset pagination).
+type ProductQuery struct {
+	Page   int           // Page is the 1-based page number used by offset pagination, ignored when After is set.
+	Limit  int           // Limit is the maximum number of products listed.
+	After  *Cursor       // After, if set, marks the last product of the previous page; the listing continues right after it.
+	Filter ProductFilter // Filter restricts the listing to the matching products.
+	Sort   []SortField   // Sort orders the listing, ties being broken by ID; products are ordered by ID if empty.
+}
+
+// ProductFilter restricts a listing to the products matching every set criterion.
+type ProductFilter struct {
+	NameContains string            // NameContains, if not empty, matches products whose name contains it, ignoring case.
+	MinPrice     *Money            // MinPrice, if set, matches products priced at least at it.
+	MaxPrice     *Money            // MaxPrice, if set, matches products priced at most at it.
+	InStock      *bool             // InStock, if set, matches products with (true) or without (false) units in stock.
+	CategoryIDs  []CategoryID      // CategoryIDs, if not empty, matches products linked to any of the categories.
+	Tags         []string          // Tags, if not empty, matches products carrying all of the normalized tags, or any of them with MatchAnyTag.
+	MatchAnyTag  bool              // MatchAnyTag matches products carrying at least one of the Tags instead of all of them.
+	Attributes   map[string]string // Attributes, if not empty, matches products having every attribute with a value whose AttributeText is the given text.
+}
+
+// SortKey names a product attribute listings can be sorted by.
+type SortKey string
+
+// The attributes listings can be sorted by.
+const (
+	SortByID          SortKey = "id"
+	SortByName        SortKey = "name"
+	SortByPrice       SortKey = "price"
+	SortByQuantity    SortKey = "quantity"
+	SortByCreatedAt   SortKey = "createdAt"
+	SortByLastUpdated SortKey = "lastUpdated"
+)
+
+// SortKeys lists every attribute listings can be sorted by.
+var SortKeys = []SortKey{SortByID, SortByName, SortByPrice, SortByQuantity, SortByCreatedAt, SortByLastUpdated}
+
+// SortField orders a listing by a single attribute.
+type SortField struct {
+	Key        SortKey // Key is the attribute the listing is ordered by.
+	Descending bool    // Descending orders the listing from the highest to the lowest value.
+}
+
+// Cursor marks the position of a product within a listing.
+// It is sent to clients as an opaque string, produced by Encode and read back by DecodeCursor.
+type Cursor struct {
+	Values []any `json:"v"` // Values are the product's values of the listing's sort keys, in the order of ProductQuery.OrderBy.
+}
+
+// ParseSort parses a comma separated list of sort keys, each optionally prefixed with "-" for descending order
+// (e.g., "-price,name").
+//
+// Parameters:
+// - spec: The sort specification.
+//
+// Returns:
+// - The parsed sort fields and nil if every key is one of SortKeys, appearing at most once.
+// - An error naming the first invalid key otherwise.
+func ParseSort(spec string) ([]SortField, error) {
+	var sort []SortField
+
+	for _, part := range strings.Split(spec, ",") {
+		field := SortField{Key: SortKey(strings.TrimSpace(part))}
+		if after, found := strings.CutPrefix(string(field.Key), "-"); found {
+			field = SortField{Key: SortKey(after), Descending: true}
+		}
+
+		if !slices.Contains(SortKeys, field.Key) {
+			return nil, fmt.Errorf("error: unknown sort key %q", field.Key)
+		}
+		if slices.ContainsFunc(sort, func(sortField SortField) bool { return sortField.Key == field.Key }) {
+			return nil, fmt.Errorf("error: duplicate sort key %q", field.Key)
+		}
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+// Normalize replaces an invalid page number with the first page and an invalid limit with DefaultPageLimit.
+func (query *ProductQuery) Normalize() {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultPageLimit
+	}
+}
+
+// Offset returns the number of products skipped before the requested page.
+// Keyset paginated queries skip no products, as the cursor already positions the listing.
+//
+// Returns:
+// - The number of products preceding the page.
+func (query *ProductQuery) Offset() int {
+	if query.After != nil {
+		return 0
+	}
+
+	return (query.Page - 1) * query.Limit
+}
+
+// OrderBy returns the complete order of the listing: the requested sort followed by the ID,
+// unless already sorted by it, so that every product has a distinct position.
+//
+// Returns:
+// - The sort fields ordering the listing.
+func (query *ProductQuery) OrderBy() []SortField {
+	orderBy := slices.Clone(query.Sort)
+	if !slices.ContainsFunc(orderBy, func(field SortField) bool { return field.Key == SortByID }) {
+		orderBy = append(orderBy, SortField{Key: SortByID})
+	}
+
+	return orderBy
+}
+
+// Matches reports whether the product satisfies every criterion of the filter, except CategoryIDs,
+// as the category links of products are only known to the stores.
+//
+// Parameters:
+// - product: The product checked against the filter.
+//
+// Returns:
+// - True if the product matches the filter; otherwise, false.
+func (filter *ProductFilter) Matches(product *Product) bool {
+	switch {
+	case filter.NameContains != "" && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(filter.NameContains)):
+		return false
+	case filter.MinPrice != nil && product.Price.Compare(*filter.MinPrice) < 0:
+		return false
+	case filter.MaxPrice != nil && product.Price.Compare(*filter.MaxPrice) > 0:
+		return false
+	case filter.InStock != nil && *filter.InStock != (product.Quantity > 0):
+		return false
+	case len(filter.Tags) > 0 && !matchesTags(product, filter.Tags, filter.MatchAnyTag):
+		return false
+	case len(filter.Attributes) > 0 && !matchesAttributes(product, filter.Attributes):
+		return false
+	}
+
+	return true
+}
+
+// CompareProducts compares the positions of two products in a listing ordered by orderBy.
+//
+// Parameters:
+// - a, b: The compared products.
+// - orderBy: The order of the listing, as returned by ProductQuery.OrderBy.
+//
+// Returns:
+// - A negative number if a precedes b, a positive number if b precedes a, or zero if their sort keys are equal.
+func CompareProducts(a, b *Product, orderBy []SortField) int {
+	return compareSortValues(sortValues(a, orderBy), sortValues(b, orderBy), orderBy)
+}
+
+// CursorAfter creates the cursor continuing a listing after the specified product.
+//
+// Parameters:
+// - product: The last product of the current page.
+// - orderBy: The order of the listing, as returned by ProductQuery.OrderBy.
+//
+// Returns:
+// - A pointer to the Cursor marking the product's position.
+func CursorAfter(product *Product, orderBy []SortField) *Cursor {
+	return &Cursor{Values: sortValues(product, orderBy)}
+}
+
+// Precedes reports whether the position marked by the cursor precedes the product in a listing ordered by orderBy,
+// that is, whether the product belongs to the pages following the cursor.
+//
+// Parameters:
+// - product: The product compared against the cursor.
+// - orderBy: The order of the listing the cursor was created for.
+//
+// Returns:
+// - True if the product follows the cursor; otherwise, false.
+func (cursor *Cursor) Precedes(product *Product, orderBy []SortField) bool {
+	return compareSortValues(cursor.Values, sortValues(product, orderBy), orderBy) < 0
+}
+
+// Encode serializes the cursor into an opaque, URL safe string.
+//
+// Returns:
+// - The encoded cursor.
+func (cursor *Cursor) Encode() string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeCursor parses a cursor previously produced by Encode for a listing ordered by orderBy.
+//
+// Parameters:
+// - encoded: The opaque cursor string sent by the client.
+// - orderBy: The order of the listing, as returned by ProductQuery.OrderBy.
+//
+// Returns:
+// - A pointer to the decoded Cursor and nil if the string is a valid cursor for the order.
+// - An error if the string is not a valid cursor or was created for a different order.
+func DecodeCursor(encoded string, orderBy []SortField) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error: malformed cursor: %v", err)
+	}
+
+	var rawCursor struct {
+		Values []json.RawMessage `json:"v"`
+	}
+	if err := json.Unmarshal(decoded, &rawCursor); err != nil {
+		return nil, fmt.Errorf("error: malformed cursor: %v", err)
+	}
+	if len(rawCursor.Values) != len(orderBy) {
+		return nil, fmt.Errorf("error: cursor does not match the sort order")
+	}
+
+	cursor := &Cursor{Values: make([]any, len(orderBy))}
+	for i, field := range orderBy {
+		if cursor.Values[i], err = decodeSortValue(field.Key, rawCursor.Values[i]); err != nil {
+			return nil, fmt.Errorf("error: cursor does not match the sort order: %v", err)
+		}
+	}
+
+	return cursor, nil
+}
+
+// sortValues returns the product's values of the sort keys, in order.
+func sortValues(product *Product, orderBy []SortField) []any {
+	values := make([]any, len(orderBy))
+	for i, field := range orderBy {
+		switch field.Key {
+		case SortByID:
+			values[i] = product.ID
+		case SortByName:
+			values[i] = product.Name
+		case SortByPrice:
+			values[i] = product.Price
+		case SortByQuantity:
+			values[i] = product.Quantity
+		case SortByCreatedAt:
+			values[i] = product.CreatedAt
+		case SortByLastUpdated:
+			values[i] = product.LastUpdated
+		}
+	}
+
+	return values
+}
+
+// decodeSortValue decodes a sort key value of a cursor into the type of the product attribute.
+func decodeSortValue(key SortKey, raw json.RawMessage) (any, error) {
+	switch key {
+	case SortByID:
+		id, err := unmarshalValue[ProductID](raw)
+		if err == nil && id < 1 {
+			err = fmt.Errorf("invalid product id %d", id)
+		}
+		return id, err
+	case SortByName:
+		return unmarshalValue[string](raw)
+	case SortByPrice:
+		return unmarshalValue[Money](raw)
+	case SortByQuantity:
+		return unmarshalValue[int](raw)
+	case SortByCreatedAt, SortByLastUpdated:
+		return unmarshalValue[time.Time](raw)
+	}
+
+	return nil, fmt.Errorf("unknown sort key %q", key)
+}
+
+// unmarshalValue decodes a JSON value into a new value of type T.
+func unmarshalValue[T any](raw json.RawMessage) (T, error) {
+	var value T
+	err := json.Unmarshal(raw, &value)
+	return value, err
+}
+
+// compareSortValues compares two lists of sort key values, produced by sortValues, in the order of orderBy.
+func compareSortValues(a, b []any, orderBy []SortField) int {
+	for i, field := range orderBy {
+		var order int
+
+		switch aValue := a[i].(type) {
+		case ProductID:
+			order = cmp.Compare(aValue, b[i].(ProductID))
+		case string:
+			order = strings.Compare(aValue, b[i].(string))
+		case Money:
+			order = aValue.Compare(b[i].(Money))
+		case int:
+			order = cmp.Compare(aValue, b[i].(int))
+		case time.Time:
+			order = aValue.Compare(b[i].(time.Time))
+		}
+
+		if field.Descending {
+			order = -order
+		}
+		if order != 0 {
+			return order
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ProductSearcher defines an interface for full-text searches over the name and description of products.
+type ProductSearcher interface {
+	// Search returns a page of the products matching any term of the SearchQuery, the most relevant first.
+	Search(context.Context, *SearchQuery) ([]*SearchHit, error)
+}
+
+// SearchQuery describes a full-text search and the page of results to return.
+type SearchQuery struct {
+	Text  string // Text is the free-form search text, split into terms by SearchTerms.
+	Page  int    // Page is the 1-based page number of the results.
+	Limit int    // Limit is the maximum number of results returned.
+}
+
+// SearchHit is a product matching a search, along with its relevance.
+type SearchHit struct {
+	*Product
+	Score float64 `json:"score"` // Score is the relevance of the product, only comparable within the same search; higher is more relevant.
+}
+
+// Tokenize splits a text into lower case terms made of letters and digits, in order, repeated terms included.
+//
+// Parameters:
+// - text: The text to split.
+//
+// Returns:
+// - The terms of the text, or an empty slice if it contains none.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+}
+
+// SearchTerms splits a search text into its distinct terms, as defined by Tokenize, in the order they first appear.
+//
+// Parameters:
+// - text: The free-form search text.
+//
+// Returns:
+// - The terms of the text, or an empty slice if it contains none.
+func SearchTerms(text string) []string {
+	var terms []string
+	for _, term := range Tokenize(text) {
+		if !slices.Contains(terms, term) {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// Normalize replaces an invalid page number with the first page and an invalid limit with DefaultPageLimit.
+func (query *SearchQuery) Normalize() {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = DefaultPageLimit
+	}
+}
+
+// Offset returns the number of results skipped before the requested page.
+//
+// Returns:
+// - The number of results preceding the page.
+func (query *SearchQuery) Offset() int {
+	return (query.Page - 1) * query.Limit
+}
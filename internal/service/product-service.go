@@ -1,6 +1,8 @@
 package service
 
 import (
+	"cmp"
+	"context"
 	"time"
 )
 
@@ -9,56 +11,91 @@ type ProductID int64
 
 // Product represents a product entity with details such as price, quantity, discount, and description.
 type Product struct {
-	Price         float64   `json:"price"`
-	CreatedAt     time.Time `json:"createdAt"`
-	LastUpdated   time.Time `json:"lastUpdated"`
-	ID            ProductID `json:"id"`
-	Quantity      int       `json:"quantity"`
-	quantityDelta int       // quantityDelta represents the change in quantity, used during updates.
-	Discount      float32   `json:"discount"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
+	Price         Money      `json:"price"` // Price is the exact unit price of the product.
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastUpdated   time.Time  `json:"lastUpdated"`
+	ID            ProductID  `json:"id"`
+	Version       int64      `json:"version"` // Version is incremented on every change, used for optimistic concurrency control.
+	Quantity      int        `json:"quantity"`
+	quantityDelta int        // quantityDelta represents the change in quantity, used during updates.
+	Discount      float32    `json:"discount"` // Discount is the percentage deducted from the price, in [0, MaxDiscount].
+	SKU           string     `json:"sku"`      // SKU is the stock keeping unit identifying the product in external systems, unique across all products.
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Tags          []string   `json:"tags"`       // Tags are the normalized free-form tags of the product, in ascending order.
+	Attributes    Attributes `json:"attributes"` // Attributes are the custom attributes of the product, conforming to the schemas of its categories.
 }
 
 // ProductCreationPayload represents the required data to create a new product.
 type ProductCreationPayload struct {
-	Price       float64 `json:"price" validate:"required,number"`
-	Quantity    int     `json:"quantity" validate:"required"`
-	Discount    float32 `json:"discount"`
-	Name        string  `json:"name" validate:"required"`
-	Description string  `json:"description"`
+	Price       Money      `json:"price" validate:"required,gt=0,money"`
+	Quantity    int        `json:"quantity" validate:"required"`
+	Discount    float32    `json:"discount" validate:"gte=0,lte=100"`
+	Currency    Currency   `json:"currency" validate:"omitempty,currency"` // Currency is the currency of the price, DefaultCurrency if empty.
+	SKU         string     `json:"sku" validate:"required,max=64"`         // SKU must not be used by another product.
+	Name        string     `json:"name" validate:"required"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags" validate:"dive,tag"`                                         // Tags are normalized as described by NormalizeTags.
+	Attributes  Attributes `json:"attributes" validate:"dive,keys,attribute,endkeys,attributevalue"` // Attributes are the custom attributes of the product.
 }
 
 // ProductUpdatePayload represents the data used to update an existing product's details.
 type ProductUpdatePayload struct {
-	Price       float64   `json:"price" default:"-1" validate:"number"`
-	ID          ProductID `json:"id" validate:"required"`
-	Quantity    int       `json:"quantity"`
-	Discount    float32   `json:"discount"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
+	Price       Money      `json:"price" default:"-1" validate:"eq=-1|gt=0,money"` // Price, unless -1 (not set), replaces the price of the product and must be positive.
+	ID          ProductID  `json:"id" validate:"required"`
+	Quantity    int        `json:"quantity"`
+	Discount    float32    `json:"discount" default:"-1" validate:"eq=-1|gte=0,lte=100"` // Discount, unless -1 (not set), replaces the discount of the product, in percent.
+	Currency    Currency   `json:"currency" validate:"omitempty,currency"`               // Currency, if not empty, replaces the currency of the price.
+	SKU         string     `json:"sku" validate:"max=64"`                                // SKU, if not empty, replaces the SKU of the product.
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Tags        *[]string  `json:"tags" validate:"omitempty,dive,tag"`                               // Tags, if set, replace the product's tags; an empty list removes them.
+	Attributes  Attributes `json:"attributes" validate:"dive,keys,attribute,endkeys,attributevalue"` // Attributes, if set, replace the product's attributes; an empty object removes them.
+}
+
+// StockAdjustmentPayload represents the number of units added to or removed from a product's stock.
+type StockAdjustmentPayload struct {
+	Amount int `json:"amount" validate:"required,gt=0"`
 }
 
 // ProductCRUDer defines an interface for CRUD operations
 // on products, including create, retrieve, update, and delete methods.
+// Every method receives a context, whose cancellation or deadline aborts the underlying storage operation.
 type ProductCRUDer interface {
 	// Create adds a new product to the store using the provided product reference.
 	// The Product parameter may be modified with additional information (e.g., ID).
-	Create(**Product) error
+	Create(context.Context, **Product) error
 
-	// RetrieveAll retrieves a list of products with optional pagination.
-	// The parameters specify the page and limit of products to retrieve.
-	RetrieveAll(int, int) ([]*Product, error)
+	// RetrieveAll retrieves a page of the products matching the ProductQuery's filter, in the order of ProductQuery.OrderBy:
+	// the requested sort keys, ties being broken by ascending ID (the whole order when no sort is requested).
+	// The page is selected by number, skipping the preceding products, or by cursor, listing the products positioned
+	// strictly after the cursor's in that order; at most the query's limit of products are returned.
+	RetrieveAll(context.Context, *ProductQuery) ([]*Product, error)
+
+	// Count returns the number of products listed by the ProductQuery across all of its pages.
+	Count(context.Context, *ProductQuery) (int, error)
 
 	// Retrieve fetches a product by its unique ID.
-	Retrieve(ProductID) (*Product, error)
+	Retrieve(context.Context, ProductID) (*Product, error)
+
+	// RetrieveBySKU fetches a product by its unique SKU.
+	RetrieveBySKU(context.Context, string) (*Product, error)
 
-	// Update modifies the details of an existing product in the store.
+	// Update modifies the details of an existing product in the store,
+	// rejecting attributes that do not conform to the attribute schemas of the product's categories.
 	// The Product parameter may be modified with additional information.
-	Update(**Product) error
+	Update(context.Context, **Product) error
 
 	// Delete removes a specified product from the store.
-	Delete(*Product) error
+	Delete(context.Context, *Product) error
+}
+
+// StockAdjuster defines an interface for atomic adjustments of a product's stock,
+// applied directly by the store so concurrent adjustments never overwrite each other.
+type StockAdjuster interface {
+	// AdjustStock adds the signed delta to the quantity of the product with the given ID,
+	// provided the stock stays non-negative, and returns the adjusted product.
+	AdjustStock(context.Context, ProductID, int) (*Product, error)
 }
 
 // NewProduct creates a new Product instance based on the provided ProductCreationPayload.
@@ -70,14 +107,17 @@ type ProductCRUDer interface {
 // - A pointer to the newly created Product instance.
 func NewProduct(productPayload *ProductCreationPayload) *Product {
 	return &Product{
-		Price:       productPayload.Price,
+		Price:       Money{Amount: productPayload.Price.Amount, Currency: cmp.Or(productPayload.Currency, DefaultCurrency)},
 		CreatedAt:   time.Now().UTC(),
 		LastUpdated: time.Now().UTC(),
 		ID:          0,
+		SKU:         productPayload.SKU,
 		Quantity:    productPayload.Quantity,
 		Discount:    productPayload.Discount,
 		Name:        productPayload.Name,
 		Description: productPayload.Description,
+		Tags:        NormalizeTags(productPayload.Tags),
+		Attributes:  cloneAttributes(productPayload.Attributes),
 	}
 }
 
@@ -87,7 +127,7 @@ func NewProduct(productPayload *ProductCreationPayload) *Product {
 // - A pointer to a ProductUpdatePayload instance with default values indicating no update.
 func NewDefaultUpdatePayload() *ProductUpdatePayload {
 	return &ProductUpdatePayload{
-		Price:       -1,
+		Price:       Money{Amount: -1},
 		ID:          0,
 		Quantity:    -1,
 		Discount:    -1,
@@ -105,6 +145,10 @@ func NewDefaultUpdatePayload() *ProductUpdatePayload {
 // This function only updates fields in the Product that are set in the payload.
 func UpdateProduct(product *Product, productUpdates *ProductUpdatePayload) {
 
+	if productUpdates.SKU != "" {
+		product.SKU = productUpdates.SKU
+	}
+
 	if productUpdates.Name != "" {
 		product.Name = productUpdates.Name
 	}
@@ -113,8 +157,12 @@ func UpdateProduct(product *Product, productUpdates *ProductUpdatePayload) {
 		product.Description = productUpdates.Description
 	}
 
-	if productUpdates.Price >= 0 {
-		product.Price = productUpdates.Price
+	if productUpdates.Price.Amount > 0 {
+		product.Price.Amount = productUpdates.Price.Amount
+	}
+
+	if productUpdates.Currency != "" {
+		product.Price.Currency = productUpdates.Currency
 	}
 
 	if productUpdates.Quantity >= 0 {
@@ -126,7 +174,15 @@ func UpdateProduct(product *Product, productUpdates *ProductUpdatePayload) {
 		product.Discount = productUpdates.Discount
 	}
 
-	product.LastUpdated = time.Now()
+	if productUpdates.Tags != nil {
+		product.Tags = NormalizeTags(*productUpdates.Tags)
+	}
+
+	if productUpdates.Attributes != nil {
+		product.Attributes = cloneAttributes(productUpdates.Attributes)
+	}
+
+	product.LastUpdated = time.Now().UTC()
 }
 
 // GetQuantityDelta returns the change in quantity during updates to a product.
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,40 +13,55 @@ import (
 func TestNewProduct(t *testing.T) {
 	t.Run("successfully creates a product with initial values", func(t *testing.T) {
 		payload := &ProductCreationPayload{
-			Price:       19.99,
+			Price:       Money{Amount: 1999},
 			Quantity:    10,
 			Discount:    5.0,
+			SKU:         "TEST-001",
 			Name:        "Test Product",
 			Description: "A test product description",
 		}
 
 		product := NewProduct(payload)
 
-		assert.Equal(t, payload.Price, product.Price)
+		assert.Equal(t, Money{Amount: 1999, Currency: DefaultCurrency}, product.Price)
 		assert.Equal(t, payload.Quantity, product.Quantity)
 		assert.Equal(t, float32(5.0), product.Discount) // Adjust type for Discount field
+		assert.Equal(t, payload.SKU, product.SKU)
 		assert.Equal(t, payload.Name, product.Name)
 		assert.Equal(t, payload.Description, product.Description)
 		assert.Equal(t, ProductID(0), product.ID)
 		assert.WithinDuration(t, time.Now().UTC(), product.CreatedAt, time.Second)
 		assert.WithinDuration(t, time.Now().UTC(), product.LastUpdated, time.Second)
 	})
+
+	t.Run("prices the product in the requested currency", func(t *testing.T) {
+		product := NewProduct(&ProductCreationPayload{Price: Money{Amount: 1999}, Currency: "USD"})
+
+		assert.Equal(t, Money{Amount: 1999, Currency: "USD"}, product.Price)
+	})
+
+	t.Run("normalizes the tags", func(t *testing.T) {
+		assert.Equal(t, []string{"sale", "summer"}, NewProduct(&ProductCreationPayload{Tags: []string{" Summer", "sale", "SALE"}}).Tags)
+		assert.Equal(t, []string{}, NewProduct(&ProductCreationPayload{}).Tags)
+	})
 }
 
 func TestUpdateProduct(t *testing.T) {
 	t.Run("updates product with new values", func(t *testing.T) {
 		product := &Product{
-			Price:       19.99,
+			Price:       Money{Amount: 1999},
 			Quantity:    10,
 			Discount:    5.0,
+			SKU:         "ORIGINAL-001",
 			Name:        "Original Product",
 			Description: "Original description",
 		}
 
 		updatePayload := &ProductUpdatePayload{
-			Price:       24.99,
+			Price:       Money{Amount: 2499},
 			Quantity:    15,
 			Discount:    10.0,
+			SKU:         "UPDATED-001",
 			Name:        "Updated Product",
 			Description: "Updated description",
 		}
@@ -53,6 +71,7 @@ func TestUpdateProduct(t *testing.T) {
 		assert.Equal(t, updatePayload.Price, product.Price)
 		assert.Equal(t, updatePayload.Quantity, product.Quantity)
 		assert.Equal(t, float32(10.0), product.Discount)
+		assert.Equal(t, updatePayload.SKU, product.SKU)
 		assert.Equal(t, updatePayload.Name, product.Name)
 		assert.Equal(t, updatePayload.Description, product.Description)
 		assert.Equal(t, 5, product.GetQuantityDelta())
@@ -61,15 +80,16 @@ func TestUpdateProduct(t *testing.T) {
 
 	t.Run("does not update fields with default values", func(t *testing.T) {
 		product := &Product{
-			Price:       19.99,
+			Price:       Money{Amount: 1999},
 			Quantity:    10,
 			Discount:    5.0,
+			SKU:         "ORIGINAL-001",
 			Name:        "Original Product",
 			Description: "Original description",
 		}
 
 		updatePayload := &ProductUpdatePayload{
-			Price:       -1,
+			Price:       Money{Amount: -1},
 			Quantity:    -1,
 			Discount:    -1,
 			Name:        "",
@@ -78,16 +98,17 @@ func TestUpdateProduct(t *testing.T) {
 
 		UpdateProduct(product, updatePayload)
 
-		assert.Equal(t, 19.99, product.Price)
+		assert.Equal(t, Money{Amount: 1999}, product.Price)
 		assert.Equal(t, 10, product.Quantity)
 		assert.Equal(t, float32(5.0), product.Discount)
+		assert.Equal(t, "ORIGINAL-001", product.SKU)
 		assert.Equal(t, "Original Product", product.Name)
 		assert.Equal(t, "Original description", product.Description)
 	})
 
 	t.Run("updates only specified fields", func(t *testing.T) {
 		product := &Product{
-			Price:       19.99,
+			Price:       Money{Amount: 1999},
 			Quantity:    10,
 			Discount:    5.0,
 			Name:        "Original Product",
@@ -97,14 +118,14 @@ func TestUpdateProduct(t *testing.T) {
 		updatePayload := &ProductUpdatePayload{
 			Name:        "Partially Updated Product",
 			Description: "",
-			Price:       25.99,
+			Price:       Money{Amount: 2599},
 			Discount:    -1,
 			Quantity:    -1,
 		}
 
 		UpdateProduct(product, updatePayload)
 
-		assert.Equal(t, 25.99, product.Price)
+		assert.Equal(t, Money{Amount: 2599}, product.Price)
 		assert.Equal(t, "Partially Updated Product", product.Name)
 
 		// These fields should not be updated
@@ -112,6 +133,29 @@ func TestUpdateProduct(t *testing.T) {
 		assert.Equal(t, float32(5.0), product.Discount)
 		assert.Equal(t, "Original description", product.Description)
 	})
+
+	t.Run("keeps the currency unless replaced", func(t *testing.T) {
+		product := &Product{Price: Money{Amount: 1999, Currency: "GBP"}}
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: 2599, Currency: DefaultCurrency}, Discount: -1, Quantity: -1})
+		assert.Equal(t, Money{Amount: 2599, Currency: "GBP"}, product.Price)
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Currency: "USD", Discount: -1, Quantity: -1})
+		assert.Equal(t, Money{Amount: 2599, Currency: "USD"}, product.Price)
+	})
+	t.Run("replaces the tags only if set", func(t *testing.T) {
+		product := &Product{Tags: []string{"sale"}}
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Discount: -1, Quantity: -1})
+		assert.Equal(t, []string{"sale"}, product.Tags)
+
+		tags := []string{"Winter", "clearance"}
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Discount: -1, Quantity: -1, Tags: &tags})
+		assert.Equal(t, []string{"clearance", "winter"}, product.Tags)
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Discount: -1, Quantity: -1, Tags: &[]string{}})
+		assert.Empty(t, product.Tags)
+	})
 }
 
 func TestGetQuantityDelta(t *testing.T) {
@@ -127,3 +171,539 @@ func TestGetQuantityDelta(t *testing.T) {
 
 	assert.Equal(t, 5, product.GetQuantityDelta())
 }
+
+func TestCursor(t *testing.T) {
+	orderBy := (&ProductQuery{Sort: []SortField{{Key: SortByPrice, Descending: true}, {Key: SortByName}}}).OrderBy()
+	product := &Product{ID: 42, Name: "Widget", Price: Money{Amount: 950, Currency: DefaultCurrency}}
+
+	t.Run("round trips through its encoding", func(t *testing.T) {
+		cursor := CursorAfter(product, orderBy)
+
+		decoded, err := DecodeCursor(cursor.Encode(), orderBy)
+		assert.NoError(t, err)
+		assert.Equal(t, cursor, decoded)
+	})
+
+	t.Run("rejects malformed cursors", func(t *testing.T) {
+		for _, encoded := range []string{"", "not base64!", "bm90IGpzb24", (&Cursor{}).Encode()} {
+			_, err := DecodeCursor(encoded, orderBy)
+			assert.Error(t, err, encoded)
+		}
+	})
+
+	t.Run("rejects cursors created for another order", func(t *testing.T) {
+		cursor := CursorAfter(product, orderBy)
+
+		_, err := DecodeCursor(cursor.Encode(), (&ProductQuery{}).OrderBy())
+		assert.Error(t, err)
+
+		_, err = DecodeCursor(cursor.Encode(), (&ProductQuery{Sort: []SortField{{Key: SortByName}, {Key: SortByPrice}}}).OrderBy())
+		assert.Error(t, err)
+	})
+
+	t.Run("precedes the products following it", func(t *testing.T) {
+		cursor := CursorAfter(product, orderBy)
+
+		assert.True(t, cursor.Precedes(&Product{ID: 1, Name: "Widget", Price: Money{Amount: 500}}, orderBy))
+		assert.True(t, cursor.Precedes(&Product{ID: 1, Name: "Zebra", Price: Money{Amount: 950}}, orderBy))
+		assert.True(t, cursor.Precedes(&Product{ID: 43, Name: "Widget", Price: Money{Amount: 950}}, orderBy))
+		assert.False(t, cursor.Precedes(product, orderBy))
+		assert.False(t, cursor.Precedes(&Product{ID: 99, Name: "Apple", Price: Money{Amount: 950}}, orderBy))
+	})
+}
+
+func TestParseSort(t *testing.T) {
+	t.Run("parses ascending and descending keys", func(t *testing.T) {
+		sort, err := ParseSort("-price,name")
+		assert.NoError(t, err)
+		assert.Equal(t, []SortField{{Key: SortByPrice, Descending: true}, {Key: SortByName}}, sort)
+	})
+
+	t.Run("rejects unknown and duplicate keys", func(t *testing.T) {
+		for _, spec := range []string{"", "color", "price,-price", "--price"} {
+			_, err := ParseSort(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestProductFilter(t *testing.T) {
+	minPrice, maxPrice, inStock := Money{Amount: 500}, Money{Amount: 1000}, true
+	filter := &ProductFilter{NameContains: "widget", MinPrice: &minPrice, MaxPrice: &maxPrice, InStock: &inStock}
+
+	assert.True(t, filter.Matches(&Product{Name: "Blue Widget", Price: Money{Amount: 500}, Quantity: 1}))
+	assert.False(t, filter.Matches(&Product{Name: "Gadget", Price: Money{Amount: 500}, Quantity: 1}))
+	assert.False(t, filter.Matches(&Product{Name: "Widget", Price: Money{Amount: 1001}, Quantity: 1}))
+	assert.False(t, filter.Matches(&Product{Name: "Widget", Price: Money{Amount: 499}, Quantity: 1}))
+	assert.False(t, filter.Matches(&Product{Name: "Widget", Price: Money{Amount: 500}, Quantity: 0}))
+	assert.True(t, (&ProductFilter{}).Matches(&Product{}))
+
+	tagged := &Product{Tags: []string{"sale", "summer"}}
+	assert.True(t, (&ProductFilter{Tags: []string{"sale", "summer"}}).Matches(tagged))
+	assert.False(t, (&ProductFilter{Tags: []string{"sale", "winter"}}).Matches(tagged))
+	assert.True(t, (&ProductFilter{Tags: []string{"sale", "winter"}, MatchAnyTag: true}).Matches(tagged))
+	assert.False(t, (&ProductFilter{Tags: []string{"winter"}, MatchAnyTag: true}).Matches(tagged))
+
+	attributed := &Product{Attributes: Attributes{"color": "red", "voltage": 230.0, "wireless": true}}
+	assert.True(t, (&ProductFilter{Attributes: map[string]string{"color": "red", "voltage": "230", "wireless": "true"}}).Matches(attributed))
+	assert.False(t, (&ProductFilter{Attributes: map[string]string{"color": "blue"}}).Matches(attributed))
+	assert.False(t, (&ProductFilter{Attributes: map[string]string{"material": "wood"}}).Matches(attributed))
+}
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"eco friendly", "sale"}, NormalizeTags([]string{"Sale", " ", "  Eco Friendly ", "sale"}))
+	assert.True(t, IsValidTag(" Sale "))
+	assert.False(t, IsValidTag("  "))
+	assert.False(t, IsValidTag(strings.Repeat("x", MaxTagLength+1)))
+}
+
+func TestProductQuery(t *testing.T) {
+	t.Run("normalizes invalid pagination", func(t *testing.T) {
+		query := &ProductQuery{Page: -1, Limit: 0}
+		query.Normalize()
+
+		assert.Equal(t, 1, query.Page)
+		assert.Equal(t, DefaultPageLimit, query.Limit)
+	})
+
+	t.Run("skips the preceding pages only without a cursor", func(t *testing.T) {
+		query := &ProductQuery{Page: 3, Limit: 10}
+		assert.Equal(t, 20, query.Offset())
+
+		query.After = &Cursor{Values: []any{ProductID(5)}}
+		assert.Equal(t, 0, query.Offset())
+	})
+}
+
+func TestSearchTerms(t *testing.T) {
+	assert.Equal(t, []string{"espresso", "machine", "2go"}, SearchTerms("Espresso-machine, ESPRESSO 2go!"))
+	assert.Empty(t, SearchTerms(" ?! "))
+}
+
+func TestMoney(t *testing.T) {
+	t.Run("parses decimal amounts", func(t *testing.T) {
+		for text, expected := range map[string]int64{"19.99": 1999, "5": 500, "0.5": 50, "-0.05": -5, "007.10": 710} {
+			money, err := ParseMoney(text, DefaultCurrency)
+			assert.NoError(t, err, text)
+			assert.Equal(t, Money{Amount: expected, Currency: DefaultCurrency}, money, text)
+		}
+	})
+
+	t.Run("rejects invalid amounts", func(t *testing.T) {
+		for _, text := range []string{"", "abc", "1.999", ".5", "1e3", "--1", "1.2.3", "99999999999999999999"} {
+			_, err := ParseMoney(text, DefaultCurrency)
+			assert.Error(t, err, text)
+		}
+	})
+
+	t.Run("reports whether amounts fit the price columns", func(t *testing.T) {
+		assert.True(t, Money{Amount: MaxAmount}.IsStorable())
+		assert.True(t, Money{Amount: -MaxAmount}.IsStorable())
+		assert.False(t, Money{Amount: MaxAmount + 1}.IsStorable())
+		assert.False(t, Money{Amount: -MaxAmount - 1}.IsStorable())
+	})
+
+	t.Run("formats amounts with two fractional digits", func(t *testing.T) {
+		assert.Equal(t, "19.99", Money{Amount: 1999}.String())
+		assert.Equal(t, "0.05", Money{Amount: 5}.String())
+		assert.Equal(t, "-0.05", Money{Amount: -5}.String())
+		assert.Equal(t, "-12.30", Money{Amount: -1230}.String())
+	})
+
+	t.Run("encodes JSON as a string and decodes strings and numbers", func(t *testing.T) {
+		encoded, err := json.Marshal(Money{Amount: 1999})
+		assert.NoError(t, err)
+		assert.Equal(t, `"19.99"`, string(encoded))
+
+		for _, data := range []string{`"19.99"`, `19.99`} {
+			var money Money
+			assert.NoError(t, json.Unmarshal([]byte(data), &money), data)
+			assert.Equal(t, Money{Amount: 1999, Currency: DefaultCurrency}, money, data)
+		}
+
+		var money Money
+		assert.Error(t, json.Unmarshal([]byte(`"nineteen"`), &money))
+		assert.Error(t, json.Unmarshal([]byte(`true`), &money))
+	})
+
+	t.Run("scans every database representation", func(t *testing.T) {
+		for _, src := range []any{[]byte("19.90"), "19.9", 19.9, 19.899999999} {
+			var money Money
+			assert.NoError(t, money.Scan(src), src)
+			assert.Equal(t, Money{Amount: 1990, Currency: DefaultCurrency}, money, src)
+		}
+
+		var money Money
+		assert.NoError(t, money.Scan(int64(12)))
+		assert.Equal(t, int64(1200), money.Amount)
+		assert.Error(t, money.Scan(nil))
+	})
+
+	t.Run("adds amounts of the same currency only", func(t *testing.T) {
+		sum, err := Money{Amount: 1999}.Add(Money{Amount: 1, Currency: DefaultCurrency})
+		assert.NoError(t, err)
+		assert.Equal(t, Money{Amount: 2000, Currency: DefaultCurrency}, sum)
+
+		difference, err := Money{Amount: 1999}.Sub(Money{Amount: 2000})
+		assert.NoError(t, err)
+		assert.True(t, difference.IsNegative())
+
+		_, err = Money{Amount: 1999, Currency: "USD"}.Add(Money{Amount: 1})
+		assert.Error(t, err)
+	})
+
+	t.Run("multiplies exactly", func(t *testing.T) {
+		// 0.1 * 3 drifts with float64, but not with minor units.
+		assert.Equal(t, Money{Amount: 30, Currency: DefaultCurrency}, Money{Amount: 10}.Mul(3))
+	})
+}
+
+func TestFinalPrice(t *testing.T) {
+	t.Run("deducts the discount percentage", func(t *testing.T) {
+		testCases := []struct {
+			price    int64
+			discount float32
+			expected int64
+		}{
+			{1999, 0, 1999},
+			{1999, 10, 1799},   // 1.999 deducted, rounded to 2.00
+			{1999, 12.5, 1749}, // 2.49875 deducted, rounded to 2.50
+			{10, 25, 7},        // 0.025 deducted, halves rounded in the buyer's favour
+			{1999, 0.1, 1997},
+			{1999, 100, 0},
+			{1999, 150, 0},
+			{1999, -5, 1999},
+		}
+
+		for _, testCase := range testCases {
+			product := &Product{Price: Money{Amount: testCase.price}, Discount: testCase.discount}
+			assert.Equal(t, Money{Amount: testCase.expected, Currency: DefaultCurrency}, product.FinalPrice(), testCase)
+		}
+	})
+
+	t.Run("is encoded along with the product", func(t *testing.T) {
+		product := &Product{ID: 7, Price: Money{Amount: 2000}, Discount: 25}
+
+		encoded, err := json.Marshal(product)
+		assert.NoError(t, err)
+
+		var fields map[string]any
+		assert.NoError(t, json.Unmarshal(encoded, &fields))
+		assert.Equal(t, "20.00", fields["price"])
+		assert.Equal(t, "15.00", fields["finalPrice"])
+		assert.Equal(t, float64(7), fields["id"])
+
+		encoded, err = json.Marshal(&SearchHit{Product: product, Score: 1.5})
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(encoded, &fields))
+		assert.Equal(t, "15.00", fields["finalPrice"])
+		assert.Equal(t, 1.5, fields["score"])
+	})
+}
+
+func TestExchangeRate(t *testing.T) {
+	exchangeRate := &ExchangeRate{Base: "EUR", Quote: "USD", Rate: 1_085_000}
+
+	t.Run("converts from the base to the quote currency", func(t *testing.T) {
+		converted, err := exchangeRate.Convert(Money{Amount: 1999, Currency: "EUR"})
+		assert.NoError(t, err)
+		assert.Equal(t, Money{Amount: 2169, Currency: "USD"}, converted) // 21.68915
+	})
+
+	t.Run("converts from the quote to the base currency", func(t *testing.T) {
+		converted, err := exchangeRate.Convert(Money{Amount: 2169, Currency: "USD"})
+		assert.NoError(t, err)
+		assert.Equal(t, Money{Amount: 1999, Currency: "EUR"}, converted) // 19.990783...
+	})
+
+	t.Run("rounds halves away from zero", func(t *testing.T) {
+		converted, err := (&ExchangeRate{Base: "EUR", Quote: "GBP", Rate: 500_000}).Convert(Money{Amount: 5, Currency: "EUR"})
+		assert.NoError(t, err)
+		assert.Equal(t, Money{Amount: 3, Currency: "GBP"}, converted)
+	})
+
+	t.Run("rejects other currencies", func(t *testing.T) {
+		_, err := exchangeRate.Convert(Money{Amount: 1999, Currency: "GBP"})
+		assert.Error(t, err)
+	})
+
+	t.Run("encodes the rate as a decimal string", func(t *testing.T) {
+		encoded, err := json.Marshal(exchangeRate.Rate)
+		assert.NoError(t, err)
+		assert.Equal(t, `"1.085000"`, string(encoded))
+
+		var rate Rate
+		assert.NoError(t, json.Unmarshal([]byte(`1.085`), &rate))
+		assert.Equal(t, exchangeRate.Rate, rate)
+		assert.Error(t, json.Unmarshal([]byte(`"1.0000001"`), &rate))
+	})
+}
+
+func TestPriceIn(t *testing.T) {
+	product := &Product{ID: 1, Price: Money{Amount: 2000, Currency: "EUR"}, Discount: 10}
+	exchangeRate := &ExchangeRate{Base: "GBP", Quote: "EUR", Rate: 1_250_000}
+
+	t.Run("keeps the prices in the product's currency", func(t *testing.T) {
+		priced, err := PriceIn(product, "EUR", nil)
+		assert.NoError(t, err)
+		assert.Same(t, product, priced.Product)
+		assert.Nil(t, priced.ExchangeRate)
+	})
+
+	t.Run("converts the prices with the exchange rate", func(t *testing.T) {
+		priced, err := PriceIn(product, "GBP", exchangeRate)
+		assert.NoError(t, err)
+		assert.Equal(t, Money{Amount: 1600, Currency: "GBP"}, priced.Price)
+		assert.Equal(t, Money{Amount: 1440, Currency: "GBP"}, priced.FinalPrice())
+		assert.Equal(t, Money{Amount: 2000, Currency: "EUR"}, product.Price, "the product is left unchanged")
+
+		encoded, err := json.Marshal(priced)
+		assert.NoError(t, err)
+
+		var fields map[string]any
+		assert.NoError(t, json.Unmarshal(encoded, &fields))
+		assert.Equal(t, "GBP", fields["currency"])
+		assert.Equal(t, "16.00", fields["price"])
+		assert.Equal(t, "14.40", fields["finalPrice"])
+		assert.Equal(t, "1.250000", fields["exchangeRate"].(map[string]any)["rate"])
+	})
+
+	t.Run("fails without an exchange rate to the currency", func(t *testing.T) {
+		_, err := PriceIn(product, "USD", nil)
+		assert.Error(t, err)
+
+		_, err = PriceIn(product, "USD", exchangeRate)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewScheduledPrice(t *testing.T) {
+	product := &Product{ID: 7, Price: Money{Amount: 1999, Currency: "USD"}}
+	effectiveAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	t.Run("keeps the product's currency by default", func(t *testing.T) {
+		scheduledPrice := NewScheduledPrice(product, &ScheduledPricePayload{Price: Money{Amount: 2499}, EffectiveAt: effectiveAt})
+
+		assert.Equal(t, ProductID(7), scheduledPrice.ProductID)
+		assert.Equal(t, Money{Amount: 2499, Currency: "USD"}, scheduledPrice.Price)
+		assert.Equal(t, time.UTC, scheduledPrice.EffectiveAt.Location())
+		assert.True(t, effectiveAt.Equal(scheduledPrice.EffectiveAt))
+	})
+
+	t.Run("uses the payload's currency if set", func(t *testing.T) {
+		scheduledPrice := NewScheduledPrice(product, &ScheduledPricePayload{Price: Money{Amount: 2499}, Currency: "GBP", EffectiveAt: effectiveAt})
+
+		assert.Equal(t, Money{Amount: 2499, Currency: "GBP"}, scheduledPrice.Price)
+	})
+
+	t.Run("encodes the prices with their currencies", func(t *testing.T) {
+		change := PriceChange{ProductID: 7, PreviousPrice: Money{Amount: 1999}, Price: Money{Amount: 2499, Currency: "USD"}, ChangedAt: effectiveAt.UTC()}
+
+		data, err := json.Marshal(change)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"productId": 7, "previousPrice": "19.99", "previousCurrency": "EUR", "price": "24.99", "currency": "USD", "changedAt": "2030-01-01T11:00:00Z"}`, string(data))
+	})
+}
+
+// countingScheduler is a PriceScheduler counting the runs of the price scheduler.
+type countingScheduler struct {
+	PriceScheduler
+	runs chan time.Time
+}
+
+func (scheduler *countingScheduler) ApplyDuePrices(ctx context.Context, now time.Time) (int, error) {
+	scheduler.runs <- now
+	return 0, nil
+}
+
+func TestRunPriceScheduler(t *testing.T) {
+	t.Run("applies the due prices every interval until canceled", func(t *testing.T) {
+		scheduler := &countingScheduler{runs: make(chan time.Time, 10)}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			RunPriceScheduler(ctx, scheduler, time.Millisecond)
+		}()
+
+		for range 3 {
+			<-scheduler.runs
+		}
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the scheduler did not stop once canceled")
+		}
+	})
+
+	t.Run("is disabled by a non-positive interval", func(t *testing.T) {
+		scheduler := &countingScheduler{runs: make(chan time.Time, 10)}
+
+		RunPriceScheduler(context.Background(), scheduler, 0)
+		assert.Empty(t, scheduler.runs)
+	})
+}
+
+func TestCategoryTree(t *testing.T) {
+	parent := func(id CategoryID) *CategoryID { return &id }
+	categories := []*Category{
+		{ID: 1, Name: "Electronics"},
+		{ID: 2, Name: "Phones", ParentID: parent(1)},
+		{ID: 3, Name: "Laptops", ParentID: parent(1)},
+		{ID: 4, Name: "Smartphones", ParentID: parent(2)},
+		{ID: 5, Name: "Books"},
+		{ID: 6, Name: "Orphan", ParentID: parent(99)},
+	}
+
+	t.Run("arranges the categories by parent and name", func(t *testing.T) {
+		tree := BuildCategoryTree(categories)
+
+		names := func(nodes []*CategoryNode) []string {
+			var names []string
+			for _, node := range nodes {
+				names = append(names, node.Name)
+			}
+			return names
+		}
+		assert.Equal(t, []string{"Books", "Electronics", "Orphan"}, names(tree))
+		assert.Equal(t, []string{"Laptops", "Phones"}, names(tree[1].Children))
+		assert.Equal(t, []string{"Smartphones"}, names(tree[1].Children[1].Children))
+		assert.Empty(t, tree[0].Children)
+	})
+
+	t.Run("encodes the children of every node", func(t *testing.T) {
+		data, err := json.Marshal(BuildCategoryTree(categories[4:5]))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `[{"id": 5, "name": "Books", "parentId": null, "createdAt": "0001-01-01T00:00:00Z", "lastUpdated": "0001-01-01T00:00:00Z", "children": []}]`, string(data))
+	})
+
+	t.Run("collects the descendants of a category", func(t *testing.T) {
+		assert.Equal(t, []CategoryID{1, 2, 3, 4}, DescendantIDs(categories, 1))
+		assert.Equal(t, []CategoryID{5}, DescendantIDs(categories, 5))
+		assert.Equal(t, []CategoryID{42}, DescendantIDs(categories, 42))
+	})
+}
+
+func TestVariant(t *testing.T) {
+	product := &Product{ID: 7, Price: Money{Amount: 1999, Currency: "USD"}}
+	createdAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("inherits the product's price without an override", func(t *testing.T) {
+		variant := NewVariant(product, &VariantCreationPayload{SKU: "SHIRT-S", Size: "S", Quantity: 3})
+
+		assert.Equal(t, ProductID(7), variant.ProductID)
+		assert.Equal(t, "SHIRT-S", variant.SKU)
+		assert.Equal(t, 3, variant.Quantity)
+		assert.Nil(t, variant.Price)
+	})
+
+	t.Run("overrides the price in the product's currency by default", func(t *testing.T) {
+		variant := NewVariant(product, &VariantCreationPayload{SKU: "SHIRT-XL", Price: &Money{Amount: 2499}})
+		assert.Equal(t, &Money{Amount: 2499, Currency: "USD"}, variant.Price)
+
+		variant = NewVariant(product, &VariantCreationPayload{SKU: "SHIRT-XL", Price: &Money{Amount: 2499}, Currency: "GBP"})
+		assert.Equal(t, &Money{Amount: 2499, Currency: "GBP"}, variant.Price)
+	})
+
+	t.Run("replaces the details but not the stock", func(t *testing.T) {
+		variant := NewVariant(product, &VariantCreationPayload{SKU: "SHIRT-XL", Price: &Money{Amount: 2499}, Quantity: 5})
+
+		UpdateVariant(product, variant, &VariantUpdatePayload{SKU: "SHIRT-XXL", Color: "red"})
+
+		assert.Equal(t, "SHIRT-XXL", variant.SKU)
+		assert.Equal(t, "red", variant.Color)
+		assert.Nil(t, variant.Price)
+		assert.Equal(t, 5, variant.Quantity)
+	})
+
+	t.Run("encodes the price override along with its currency", func(t *testing.T) {
+		variant := Variant{ID: 2, ProductID: 7, SKU: "SHIRT-XL", Size: "XL", Price: &Money{Amount: 2499, Currency: "GBP"}, Quantity: 1, CreatedAt: createdAt, LastUpdated: createdAt}
+
+		data, err := json.Marshal(variant)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id": 2, "productId": 7, "sku": "SHIRT-XL", "size": "XL", "color": "", "price": "24.99", "currency": "GBP", "quantity": 1, "createdAt": "2030-01-01T12:00:00Z", "lastUpdated": "2030-01-01T12:00:00Z"}`, string(data))
+
+		variant.Price = nil
+		data, err = json.Marshal(variant)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"id": 2, "productId": 7, "sku": "SHIRT-XL", "size": "XL", "color": "", "price": null, "quantity": 1, "createdAt": "2030-01-01T12:00:00Z", "lastUpdated": "2030-01-01T12:00:00Z"}`, string(data))
+	})
+}
+
+func TestAttributes(t *testing.T) {
+	t.Run("validates names and values", func(t *testing.T) {
+		assert.True(t, IsValidAttributeName("max_voltage-2"))
+		assert.False(t, IsValidAttributeName(""))
+		assert.False(t, IsValidAttributeName("color.name"))
+		assert.False(t, IsValidAttributeName(strings.Repeat("x", MaxAttributeNameLength+1)))
+
+		for _, value := range []any{"red", 1.5, false} {
+			assert.True(t, IsValidAttributeValue(value), value)
+		}
+		for _, value := range []any{nil, []any{"red"}, map[string]any{}} {
+			assert.False(t, IsValidAttributeValue(value), value)
+		}
+	})
+
+	t.Run("renders values as text", func(t *testing.T) {
+		assert.Equal(t, "red", AttributeText("red"))
+		assert.Equal(t, "230", AttributeText(230.0))
+		assert.Equal(t, "0.25", AttributeText(0.25))
+		assert.Equal(t, "true", AttributeText(true))
+	})
+
+	t.Run("checks attributes against the category schemas", func(t *testing.T) {
+		electronics := &Category{ID: 1, AttributeSchema: AttributeSchema{
+			"voltage":  {Type: AttributeNumber, Required: true},
+			"wireless": {Type: AttributeBoolean},
+		}}
+		lighting := &Category{ID: 2, AttributeSchema: AttributeSchema{"voltage": {Type: AttributeNumber, Required: true}}}
+		categories := []*Category{electronics, lighting, {ID: 3}}
+
+		assert.Nil(t, CheckAttributes(Attributes{"voltage": 230.0, "color": "red"}, categories))
+		assert.Equal(t, []AttributeError{
+			{Name: "voltage", Tag: "required", Type: "number"},
+			{Name: "wireless", Tag: "type", Type: "boolean"},
+		}, CheckAttributes(Attributes{"wireless": "yes"}, categories))
+		assert.Nil(t, CheckAttributes(nil, nil))
+	})
+
+	t.Run("stores attributes as a JSON object", func(t *testing.T) {
+		value, err := Attributes{"color": "red", "voltage": 230.0}.Value()
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"color": "red", "voltage": 230}`, value.(string))
+
+		value, err = Attributes(nil).Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "{}", value)
+
+		var attributes Attributes
+		assert.NoError(t, attributes.Scan([]byte(`{"color": "red", "wireless": true}`)))
+		assert.Equal(t, Attributes{"color": "red", "wireless": true}, attributes)
+
+		assert.NoError(t, attributes.Scan(nil))
+		assert.Equal(t, Attributes{}, attributes)
+
+		var schema AttributeSchema
+		assert.NoError(t, schema.Scan(`{"voltage": {"type": "number", "required": true}}`))
+		assert.Equal(t, AttributeSchema{"voltage": {Type: AttributeNumber, Required: true}}, schema)
+	})
+
+	t.Run("copies the payload attributes", func(t *testing.T) {
+		attributes := Attributes{"color": "red"}
+		product := NewProduct(&ProductCreationPayload{Name: "Lamp", Attributes: attributes})
+		attributes["color"] = "blue"
+		assert.Equal(t, Attributes{"color": "red"}, product.Attributes)
+
+		assert.Equal(t, Attributes{}, NewProduct(&ProductCreationPayload{Name: "Plain"}).Attributes)
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Quantity: -1, Discount: -1})
+		assert.Equal(t, Attributes{"color": "red"}, product.Attributes)
+
+		UpdateProduct(product, &ProductUpdatePayload{Price: Money{Amount: -1}, Quantity: -1, Discount: -1, Attributes: Attributes{}})
+		assert.Equal(t, Attributes{}, product.Attributes)
+	})
+}
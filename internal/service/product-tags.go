@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the maximum number of characters of a tag.
+const MaxTagLength = 64
+
+// TagCount is the number of products carrying a tag, as listed by TagCounter.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagCounter defines an interface for listing the tags in use along with the number of products carrying them.
+type TagCounter interface {
+	// RetrieveTagCounts lists every tag carried by at least one product, the most used first, ties being ordered by tag.
+	RetrieveTagCounts(context.Context) ([]*TagCount, error)
+}
+
+// IsValidTag reports whether the tag has between one and MaxTagLength characters, ignoring surrounding whitespace.
+//
+// Parameters:
+// - tag: The tag to check.
+//
+// Returns:
+// - True if the tag is valid; otherwise, false.
+func IsValidTag(tag string) bool {
+	length := utf8.RuneCountInString(strings.TrimSpace(tag))
+	return length > 0 && length <= MaxTagLength
+}
+
+// NormalizeTags trims and lower-cases the tags, dropping empty and repeated ones, so tags differing only in case
+// or surrounding whitespace are the same tag.
+//
+// Parameters:
+// - tags: The tags to normalize.
+//
+// Returns:
+// - The distinct normalized tags in ascending order, empty but not nil if there are none.
+func NormalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// matchesTags reports whether the product carries all of the tags, or at least one of them if matchAny is set.
+func matchesTags(product *Product, tags []string, matchAny bool) bool {
+	if matchAny {
+		return slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(product.Tags, tag) })
+	}
+
+	return !slices.ContainsFunc(tags, func(tag string) bool { return !slices.Contains(product.Tags, tag) })
+}
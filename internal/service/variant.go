@@ -0,0 +1,127 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// VariantID is a unique identifier type for product variants.
+type VariantID int64
+
+// Variant is a purchasable variation of a product (e.g., a size and color of a garment), with its own SKU and stock.
+type Variant struct {
+	ID          VariantID `json:"id"`
+	ProductID   ProductID `json:"productId"`
+	SKU         string    `json:"sku"` // SKU is the stock keeping unit of the variant, unique across all variants.
+	Size        string    `json:"size"`
+	Color       string    `json:"color"`
+	Price       *Money    `json:"price"` // Price overrides the price of the product, nil if the variant is sold at the product's price.
+	Quantity    int       `json:"quantity"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// VariantCreationPayload represents the required data to create a new variant of a product.
+type VariantCreationPayload struct {
+	SKU      string   `json:"sku" validate:"required,max=64"`
+	Size     string   `json:"size" validate:"max=64"`
+	Color    string   `json:"color" validate:"max=64"`
+	Price    *Money   `json:"price" validate:"omitempty,gt=0,money"`  // Price, if set, overrides the price of the product.
+	Currency Currency `json:"currency" validate:"omitempty,currency"` // Currency is the currency of the price, the product's currency if empty.
+	Quantity int      `json:"quantity" validate:"gte=0"`
+}
+
+// VariantUpdatePayload represents the data replacing the details of an existing variant.
+// The stock of a variant is changed through stock adjustments only.
+type VariantUpdatePayload struct {
+	SKU      string   `json:"sku" validate:"required,max=64"`
+	Size     string   `json:"size" validate:"max=64"`
+	Color    string   `json:"color" validate:"max=64"`
+	Price    *Money   `json:"price" validate:"omitempty,gt=0,money"`  // Price, if set, overrides the price of the product; otherwise, the override is removed.
+	Currency Currency `json:"currency" validate:"omitempty,currency"` // Currency is the currency of the price, the product's currency if empty.
+}
+
+// VariantManager defines an interface for managing the variants of products, including atomic adjustments of their stock.
+type VariantManager interface {
+	// CreateVariant adds a new variant to the product with the Variant's ProductID.
+	// The Variant parameter may be modified with additional information (e.g., ID).
+	CreateVariant(context.Context, *Variant) error
+
+	// RetrieveVariants lists the variants of the product with the given ID, ordered by ID.
+	RetrieveVariants(context.Context, ProductID) ([]*Variant, error)
+
+	// RetrieveVariant fetches a variant of the product with the given ID by its unique ID.
+	RetrieveVariant(context.Context, ProductID, VariantID) (*Variant, error)
+
+	// UpdateVariant replaces the SKU, size, color and price of an existing variant, leaving its stock unchanged.
+	// The Variant parameter may be modified with additional information.
+	UpdateVariant(context.Context, *Variant) error
+
+	// DeleteVariant removes a variant of the product with the given ID.
+	DeleteVariant(context.Context, ProductID, VariantID) error
+
+	// AdjustVariantStock adds the signed delta to the quantity of a variant of the product with the given ID,
+	// provided the stock stays non-negative, and returns the adjusted variant.
+	AdjustVariantStock(context.Context, ProductID, VariantID, int) (*Variant, error)
+}
+
+// NewVariant creates a new Variant of the product based on the provided VariantCreationPayload.
+//
+// Parameters:
+// - product: The product the variant belongs to.
+// - payload: The payload containing variant creation details.
+//
+// Returns:
+// - A pointer to the newly created Variant instance.
+func NewVariant(product *Product, payload *VariantCreationPayload) *Variant {
+	return &Variant{
+		ProductID:   product.ID,
+		SKU:         payload.SKU,
+		Size:        payload.Size,
+		Color:       payload.Color,
+		Price:       variantPrice(product, payload.Price, payload.Currency),
+		Quantity:    payload.Quantity,
+		CreatedAt:   time.Now().UTC(),
+		LastUpdated: time.Now().UTC(),
+	}
+}
+
+// UpdateVariant replaces the details of the variant with those of the VariantUpdatePayload.
+//
+// Parameters:
+// - product: The product the variant belongs to.
+// - variant: The variant to update.
+// - payload: The payload containing the new variant details.
+func UpdateVariant(product *Product, variant *Variant, payload *VariantUpdatePayload) {
+	variant.SKU = payload.SKU
+	variant.Size = payload.Size
+	variant.Color = payload.Color
+	variant.Price = variantPrice(product, payload.Price, payload.Currency)
+	variant.LastUpdated = time.Now().UTC()
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the price override along with its currency.
+func (variant Variant) MarshalJSON() ([]byte, error) {
+	type variantFields Variant
+
+	var currency Currency
+	if variant.Price != nil {
+		currency = variant.Price.currency()
+	}
+
+	return json.Marshal(struct {
+		variantFields
+		Currency Currency `json:"currency,omitempty"`
+	}{variantFields(variant), currency})
+}
+
+// variantPrice returns the price override of a variant, in the given currency or the product's currency if empty.
+func variantPrice(product *Product, price *Money, currency Currency) *Money {
+	if price == nil {
+		return nil
+	}
+
+	return &Money{Amount: price.Amount, Currency: cmp.Or(currency, product.Price.currency())}
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"slices"
+)
+
+// categoryColumns lists the categories table columns in the order expected by queryCategories.
+const categoryColumns = `id, name, parentID, attributeSchema, createdAt, lastUpdated`
+
+// productCategoriesQuery selects the categories a product is linked to, ordered by name and ID.
+const productCategoriesQuery = `SELECT categories.id, categories.name, categories.parentID, categories.attributeSchema, categories.createdAt, categories.lastUpdated` +
+	` FROM categories JOIN product_categories ON product_categories.categoryID = categories.id` +
+	` WHERE product_categories.productID = ? ORDER BY categories.name, categories.id`
+
+// CreateCategory inserts a new category into the database and updates the provided category with its generated ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - category: A pointer to the Category to insert, updated with its ID.
+//
+// Returns:
+// - An error wrapping ErrConflict if the parent category does not exist, or an error if the insertion fails; otherwise, nil.
+func (store *sqlStore) CreateCategory(ctx context.Context, category *service.Category) error {
+	query := `INSERT INTO categories (name, parentID, attributeSchema, createdAt, lastUpdated) VALUES (?, ?, ?, ?, ?)`
+
+	id, err := store.insert(ctx, store.db, query, category.Name, category.ParentID, category.AttributeSchema, category.CreatedAt, category.LastUpdated)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	category.ID = service.CategoryID(id)
+	return nil
+}
+
+// RetrieveCategory fetches a category by its unique ID from the database.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique CategoryID of the category to retrieve.
+//
+// Returns:
+// - A pointer to the retrieved Category and nil if successful.
+// - An error wrapping ErrNotFound if the category does not exist, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveCategory(ctx context.Context, id service.CategoryID) (*service.Category, error) {
+	categories, err := store.queryCategories(ctx, store.db, `SELECT `+categoryColumns+` FROM categories WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("error: category with id %d: %w", id, ErrNotFound)
+	}
+
+	return categories[0], nil
+}
+
+// RetrieveCategories retrieves every category, ordered by name and ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+//
+// Returns:
+// - A slice of Category pointers and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) RetrieveCategories(ctx context.Context) ([]*service.Category, error) {
+	return store.queryCategories(ctx, store.db, `SELECT `+categoryColumns+` FROM categories ORDER BY name, id`)
+}
+
+// UpdateCategory replaces the name, parent and attribute schema of an existing category. The new parent is checked
+// against the category's descendants, and the attributes of the products linked to the category against the new schema,
+// within the same transaction, so concurrent moves never make a category its own ancestor.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - category: A pointer to the Category holding the new details.
+//
+// Returns:
+// - An error wrapping ErrConflict if the parent is the category itself, one of its descendants or does not exist.
+// - A NonconformingAttributesError if the attributes of a linked product do not conform to the new schema.
+// - An error wrapping ErrNotFound if the category does not exist, or an error if the update fails; otherwise, nil.
+func (store *sqlStore) UpdateCategory(ctx context.Context, category *service.Category) error {
+	return store.inTransaction(ctx, func(tx *sql.Tx) error {
+		if category.ParentID != nil {
+			query := `SELECT ` + categoryColumns + ` FROM categories`
+			if store.dialect.lockingReads {
+				query += ` FOR UPDATE`
+			}
+
+			categories, err := store.queryCategories(ctx, tx, query)
+			if err != nil {
+				return err
+			}
+
+			if slices.Contains(service.DescendantIDs(categories, category.ID), *category.ParentID) {
+				return fmt.Errorf("error: category with id %d cannot be moved under category with id %d: %w", category.ID, *category.ParentID, ErrConflict)
+			}
+		}
+
+		query := `UPDATE categories SET name = ?, parentID = ?, attributeSchema = ?, lastUpdated = ? WHERE id = ?`
+		result, err := tx.ExecContext(ctx, store.rebind(query), category.Name, category.ParentID, category.AttributeSchema, category.LastUpdated, category.ID)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("error: category with id %d: %w", category.ID, ErrNotFound)
+		}
+
+		return store.checkCategoryProducts(ctx, tx, category)
+	})
+}
+
+// DeleteCategory removes a category from the database, along with its links to products.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique CategoryID of the category to delete.
+//
+// Returns:
+// - An error wrapping ErrConflict if the category has subcategories.
+// - An error wrapping ErrNotFound if the category does not exist, an error if the deletion fails; otherwise, nil.
+func (store *sqlStore) DeleteCategory(ctx context.Context, id service.CategoryID) error {
+	result, err := store.db.ExecContext(ctx, store.rebind(`DELETE FROM categories WHERE id = ?`), id)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("error: category with id %d: %w", id, ErrNotFound)
+	}
+
+	return nil
+}
+
+// SetProductCategories replaces the categories a product is linked to, within a single transaction.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product.
+// - categoryIDs: The IDs of the categories the product is linked to; duplicates are ignored.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product does not exist.
+// - An error wrapping ErrConflict if a category does not exist.
+// - A NonconformingAttributesError if the attributes of the product do not conform to the schemas of the categories.
+// - An error if the update fails; otherwise, nil.
+func (store *sqlStore) SetProductCategories(ctx context.Context, id service.ProductID, categoryIDs []service.CategoryID) error {
+	return store.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := store.lockPrice(ctx, tx, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, store.rebind(`DELETE FROM product_categories WHERE productID = ?`), id); err != nil {
+			return store.classify(err)
+		}
+
+		distinct := slices.Clone(categoryIDs)
+		slices.Sort(distinct)
+		for _, categoryID := range slices.Compact(distinct) {
+			query := `INSERT INTO product_categories (productID, categoryID) VALUES (?, ?)`
+			if _, err := tx.ExecContext(ctx, store.rebind(query), id, categoryID); err != nil {
+				return store.classify(err)
+			}
+		}
+
+		var attributes service.Attributes
+		if err := tx.QueryRowContext(ctx, store.rebind(`SELECT attributes FROM products WHERE id = ?`), id).Scan(&attributes); err != nil {
+			return store.classify(err)
+		}
+
+		categories, err := store.queryCategories(ctx, tx, productCategoriesQuery, id)
+		if err != nil {
+			return err
+		}
+
+		if attributeErrs := service.CheckAttributes(attributes, categories); attributeErrs != nil {
+			return &NonconformingAttributesError{ProductID: id, Errors: attributeErrs}
+		}
+
+		return nil
+	})
+}
+
+// RetrieveProductCategories retrieves the categories a product is linked to, ordered by name and ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product.
+//
+// Returns:
+// - A slice of Category pointers and nil if successful.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveProductCategories(ctx context.Context, id service.ProductID) ([]*service.Category, error) {
+	if _, err := store.Retrieve(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return store.queryCategories(ctx, store.db, productCategoriesQuery, id)
+}
+
+// checkCategoryProducts checks the attributes of the products linked to a category against its schema,
+// locking the products on databases supporting locking reads.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the category was updated in.
+// - category: The updated category.
+//
+// Returns:
+// - A NonconformingAttributesError for the first product, by ID, whose attributes do not conform to the schema.
+// - An error if the query fails; otherwise, nil.
+func (store *sqlStore) checkCategoryProducts(ctx context.Context, tx *sql.Tx, category *service.Category) error {
+	if len(category.AttributeSchema) == 0 {
+		return nil
+	}
+
+	query := `SELECT products.id, products.attributes FROM products` +
+		` JOIN product_categories ON product_categories.productID = products.id` +
+		` WHERE product_categories.categoryID = ? ORDER BY products.id`
+	if store.dialect.lockingReads {
+		query += ` FOR UPDATE`
+	}
+
+	rows, err := tx.QueryContext(ctx, store.rebind(query), category.ID)
+	if err != nil {
+		return store.classify(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id service.ProductID
+		var attributes service.Attributes
+		if err := rows.Scan(&id, &attributes); err != nil {
+			return store.classify(err)
+		}
+
+		if attributeErrs := service.CheckAttributes(attributes, []*service.Category{category}); attributeErrs != nil {
+			return &NonconformingAttributesError{ProductID: id, Errors: attributeErrs}
+		}
+	}
+
+	return store.classify(rows.Err())
+}
+
+// queryCategories runs a query selecting the categoryColumns and scans every resulting category.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - db: The connection pool or transaction the query runs in.
+// - query: The query, written with ? placeholders.
+// - args: The values bound to the query placeholders.
+//
+// Returns:
+// - A slice of Category pointers and nil if successful.
+// - An error if the query or scanning fails.
+func (store *sqlStore) queryCategories(ctx context.Context, db queryer, query string, args ...any) ([]*service.Category, error) {
+	rows, err := db.QueryContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var categories []*service.Category
+	for rows.Next() {
+		category := new(service.Category)
+		if err := rows.Scan(&category.ID, &category.Name, &category.ParentID, &category.AttributeSchema, &category.CreatedAt, &category.LastUpdated); err != nil {
+			return nil, store.classify(err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"ntsiris/product-microservice/internal/service"
+	"strings"
+	"syscall"
+)
+
+// Sentinel errors returned, wrapped with additional context, by every ProductStore implementation.
+// Callers should match them with errors.Is.
+var (
+	// ErrNotFound indicates that the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+
+	// ErrConflict indicates that the operation conflicts with an existing record (e.g., a duplicate key).
+	ErrConflict = errors.New("conflicting record")
+
+	// ErrInsufficientStock indicates that a quantity change would drive a product's stock below zero.
+	ErrInsufficientStock = errors.New("insufficient stock")
+
+	// ErrVersionMismatch indicates that the record was modified since the version the operation is conditioned on.
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	// ErrNonconformingAttributes indicates that the attributes of a product do not conform to the attribute schema of one of its categories.
+	ErrNonconformingAttributes = errors.New("attributes not conforming to the category schemas")
+
+	// ErrUnavailable indicates that the storage could not be reached or is temporarily unable to serve requests.
+	ErrUnavailable = errors.New("storage unavailable")
+)
+
+// InsufficientStockError describes a quantity change rejected because it would drive the stock of a product,
+// or of one of its variants, below zero. It matches ErrInsufficientStock with errors.Is.
+type InsufficientStockError struct {
+	ProductID service.ProductID `json:"productId"`           // ProductID identifies the product whose stock is insufficient.
+	VariantID service.VariantID `json:"variantId,omitempty"` // VariantID identifies the variant whose stock is insufficient, zero for the product itself.
+	Requested int               `json:"requested"`           // Requested is the number of units the change attempted to remove.
+	Available int               `json:"available"`           // Available is the number of units in stock when the change was rejected.
+}
+
+// Error implements the error interface for InsufficientStockError.
+//
+// Returns:
+// - A string describing the requested and available quantities.
+func (err *InsufficientStockError) Error() string {
+	if err.VariantID != 0 {
+		return fmt.Sprintf("error: variant with id %d of product with id %d: %v: requested %d, available %d", err.VariantID, err.ProductID, ErrInsufficientStock, err.Requested, err.Available)
+	}
+
+	return fmt.Sprintf("error: product with id %d: %v: requested %d, available %d", err.ProductID, ErrInsufficientStock, err.Requested, err.Available)
+}
+
+// Is reports whether the target is ErrInsufficientStock, allowing errors.Is to match the sentinel error.
+//
+// Parameters:
+// - target: The error compared against.
+//
+// Returns:
+// - True if the target is ErrInsufficientStock; otherwise, false.
+func (err *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// NonconformingAttributesError describes a change of the categories a product is linked to, or of the attribute
+// schema of a category, rejected because the attributes of a product would not conform to the schemas of its categories.
+// It matches ErrNonconformingAttributes with errors.Is.
+type NonconformingAttributesError struct {
+	ProductID service.ProductID        `json:"productId"` // ProductID identifies the product whose attributes do not conform.
+	Errors    []service.AttributeError `json:"errors"`    // Errors lists the violations of the schemas, as returned by service.CheckAttributes.
+}
+
+// Error implements the error interface for NonconformingAttributesError.
+//
+// Returns:
+// - A string naming the product and the attributes that do not conform.
+func (err *NonconformingAttributesError) Error() string {
+	names := make([]string, 0, len(err.Errors))
+	for _, attributeErr := range err.Errors {
+		names = append(names, attributeErr.Name)
+	}
+
+	return fmt.Sprintf("error: product with id %d: %v: %s", err.ProductID, ErrNonconformingAttributes, strings.Join(names, ", "))
+}
+
+// Is reports whether the target is ErrNonconformingAttributes, allowing errors.Is to match the sentinel error.
+//
+// Parameters:
+// - target: The error compared against.
+//
+// Returns:
+// - True if the target is ErrNonconformingAttributes; otherwise, false.
+func (err *NonconformingAttributesError) Is(target error) bool {
+	return target == ErrNonconformingAttributes
+}
+
+// classifyError wraps a database error with the sentinel error describing it, leaving unknown errors untouched.
+//
+// Parameters:
+// - err: The error returned by the database driver.
+// - classifyDriverError: The optional dialect specific classification, returning nil for errors it does not recognize.
+//
+// Returns:
+// - The error wrapped with its sentinel error, or the error itself if it cannot be classified.
+func classifyError(err error, classifyDriverError func(error) error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sentinel error
+	if classifyDriverError != nil {
+		sentinel = classifyDriverError(err)
+	}
+	if sentinel == nil {
+		sentinel = classifyCommonError(err)
+	}
+
+	if sentinel == nil || errors.Is(err, sentinel) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// classifyCommonError recognizes the database/sql, network and deadline errors shared by every driver.
+//
+// Parameters:
+// - err: The error returned by the database driver.
+//
+// Returns:
+// - The matching sentinel error, or nil if the error is not recognized.
+func classifyCommonError(err error) error {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr):
+		return ErrUnavailable
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+)
+
+// exchangeRateColumns lists the exchange_rates table columns in the order expected by scanIntoExchangeRate.
+const exchangeRateColumns = `baseCurrency, quoteCurrency, rate, updatedAt`
+
+// SaveExchangeRate inserts the rate from the exchange rate's base to its quote currency, replacing any stored one,
+// and updates the provided exchange rate with the stored details.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - exchangeRate: A pointer to the ExchangeRate to save, updated with the stored details.
+//
+// Returns:
+// - An error if the insertion fails; otherwise, nil.
+func (store *sqlStore) SaveExchangeRate(ctx context.Context, exchangeRate *service.ExchangeRate) error {
+	query := `INSERT INTO exchange_rates (` + exchangeRateColumns + `) VALUES (?, ?, ?, ?)`
+	if store.dialect.onDuplicateKey {
+		query += ` ON DUPLICATE KEY UPDATE rate = VALUES(rate), updatedAt = VALUES(updatedAt)`
+	} else {
+		query += ` ON CONFLICT (baseCurrency, quoteCurrency) DO UPDATE SET rate = excluded.rate, updatedAt = excluded.updatedAt`
+	}
+
+	_, err := store.db.ExecContext(ctx, store.rebind(query), exchangeRate.Base, exchangeRate.Quote, exchangeRate.Rate, exchangeRate.UpdatedAt)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	saved, err := store.RetrieveExchangeRate(ctx, exchangeRate.Base, exchangeRate.Quote)
+	if err != nil {
+		return fmt.Errorf("error: could not retrieve saved exchange rate: %w", err)
+	}
+
+	*exchangeRate = *saved
+	return nil
+}
+
+// RetrieveExchangeRate fetches the rate between two currencies. The rate stored from the first currency to the
+// second is preferred over the one stored from the second to the first, which converts amounts by division.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - from: The currency amounts are converted from.
+// - to: The currency amounts are converted to.
+//
+// Returns:
+// - A pointer to the retrieved ExchangeRate and nil if successful.
+// - An error wrapping ErrNotFound if no rate is stored between the currencies, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveExchangeRate(ctx context.Context, from service.Currency, to service.Currency) (*service.ExchangeRate, error) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates` +
+		` WHERE (baseCurrency = ? AND quoteCurrency = ?) OR (baseCurrency = ? AND quoteCurrency = ?)` +
+		` ORDER BY CASE WHEN baseCurrency = ? THEN 0 ELSE 1 END LIMIT 1`
+
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), from, to, to, from, from)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		exchangeRate, err := scanIntoExchangeRate(rows)
+		return exchangeRate, store.classify(err)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return nil, fmt.Errorf("error: exchange rate between %s and %s: %w", from, to, ErrNotFound)
+}
+
+// RetrieveExchangeRates retrieves every stored exchange rate, ordered by base and quote currency.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+//
+// Returns:
+// - A slice of ExchangeRate pointers and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) RetrieveExchangeRates(ctx context.Context) ([]*service.ExchangeRate, error) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates ORDER BY baseCurrency, quoteCurrency`
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var exchangeRates []*service.ExchangeRate
+	for rows.Next() {
+		exchangeRate, err := scanIntoExchangeRate(rows)
+		if err != nil {
+			return nil, store.classify(err)
+		}
+
+		exchangeRates = append(exchangeRates, exchangeRate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return exchangeRates, nil
+}
+
+// DeleteExchangeRate removes the rate stored from the base to the quote currency.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - base: The base currency of the rate.
+// - quote: The quote currency of the rate.
+//
+// Returns:
+// - An error wrapping ErrNotFound if no such rate is stored, an error if the deletion fails; otherwise, nil.
+func (store *sqlStore) DeleteExchangeRate(ctx context.Context, base service.Currency, quote service.Currency) error {
+	query := `DELETE FROM exchange_rates WHERE baseCurrency = ? AND quoteCurrency = ?`
+	result, err := store.db.ExecContext(ctx, store.rebind(query), base, quote)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("error: exchange rate from %s to %s: %w", base, quote, ErrNotFound)
+	}
+
+	return nil
+}
+
+// scanIntoExchangeRate scans the result rows into an ExchangeRate instance.
+//
+// Parameters:
+// - rows: A pointer to sql.Rows containing the exchange rate data.
+//
+// Returns:
+// - A pointer to a populated ExchangeRate instance and nil if successful.
+// - An error if scanning fails.
+func scanIntoExchangeRate(rows *sql.Rows) (*service.ExchangeRate, error) {
+	exchangeRate := new(service.ExchangeRate)
+	err := rows.Scan(&exchangeRate.Base, &exchangeRate.Quote, &exchangeRate.Rate, &exchangeRate.UpdatedAt)
+
+	return exchangeRate, err
+}
@@ -0,0 +1,51 @@
+package storage_test
+
+import (
+	"ntsiris/product-microservice/internal/config"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/storage/storagetest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newServerStoreFactory returns a factory for stores backed by a database server, configured through
+// TEST_DB_<DRIVER>_ADDRESS, _USER, _PASSWORD and _NAME. The test is skipped when no address is set.
+// Every store starts from an empty schema, so the configured database must be dedicated to the tests.
+func newServerStoreFactory(t *testing.T, driver, prefix string) storagetest.StoreFactory {
+	address, ok := os.LookupEnv(prefix + "_ADDRESS")
+	if !ok {
+		t.Skipf("%s_ADDRESS is not set, skipping %s integration tests", prefix, driver)
+	}
+
+	storageConfig := config.StorageConfig{
+		Driver:   driver,
+		User:     os.Getenv(prefix + "_USER"),
+		Password: os.Getenv(prefix + "_PASSWORD"),
+		Address:  address,
+		Name:     os.Getenv(prefix + "_NAME"),
+		SSLMode:  "disable",
+	}
+	migrationPath := filepath.Join("..", "..", "migrations", driver)
+
+	return func(t *testing.T) storage.ProductStore {
+		store, err := storage.NewProductStore(driver)
+		require.NoError(t, err)
+		require.NoError(t, store.InitStore(&storageConfig))
+		require.NoError(t, store.RunMigrationDown(migrationPath))
+		require.NoError(t, store.RunMigrationUp(migrationPath))
+		t.Cleanup(func() { store.Close() })
+
+		return store
+	}
+}
+
+func TestMySQLStore(t *testing.T) {
+	storagetest.Run(t, newServerStoreFactory(t, storage.SQL_DRIVER, "TEST_DB_MYSQL"))
+}
+
+func TestPostgresStore(t *testing.T) {
+	storagetest.Run(t, newServerStoreFactory(t, storage.POSTGRES_DRIVER, "TEST_DB_POSTGRES"))
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"ntsiris/product-microservice/internal/config"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	postgresMigrate "github.com/golang-migrate/migrate/v4/database/postgres" // PostgreSQL driver
+	_ "github.com/golang-migrate/migrate/v4/source/file"                     // File source driver
+	"github.com/lib/pq"                                                      // PostgreSQL database/sql driver
+)
+
+// PostgresStore is a struct that provides methods for interacting with a PostgreSQL database.
+// It supports CRUD operations, provided by the embedded sqlStore, and schema migrations for product data.
+type PostgresStore struct {
+	sqlStore
+	dbURL string
+}
+
+// POSTGRES_DRIVER is a constant that specifies the database driver used for PostgreSQL.
+const POSTGRES_DRIVER string = "postgres"
+
+// InitStore initializes the PostgreSQL store connection using the provided StorageConfig.
+//
+// Parameters:
+// - config: A pointer to a StorageConfig struct containing database connection settings.
+//
+// Returns:
+// - An error if the connection initialization fails; otherwise, nil.
+func (postgresStore *PostgresStore) InitStore(config *config.StorageConfig) error {
+	postgresURL := url.URL{
+		Scheme:   POSTGRES_DRIVER,
+		User:     url.UserPassword(config.User, config.Password),
+		Host:     config.Address,
+		Path:     config.Name,
+		RawQuery: url.Values{"sslmode": {config.SSLMode}, "timezone": {"UTC"}}.Encode(),
+	}
+
+	postgresStore.dbURL = postgresURL.String()
+	db, err := sql.Open(POSTGRES_DRIVER, postgresStore.dbURL)
+
+	if err != nil {
+		return fmt.Errorf("error: could not acquire storage connection handle: %v", err)
+	}
+
+	postgresStore.sqlStore = sqlStore{db: db, dialect: dialect{numberedPlaceholders: true, lockingReads: true, insertReturning: true, classifyDriverError: classifyPostgresError, fullTextMatch: postgresFullTextMatch, attributeText: postgresAttributeText}}
+
+	return nil
+}
+
+func (postgresStore *PostgresStore) setUpMigration(migrationPath string) (*migrate.Migrate, error) {
+	driver, err := postgresMigrate.WithInstance(postgresStore.db, &postgresMigrate.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("error: could not initialize migration driver: %v", err)
+	}
+
+	migrator, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", migrationPath),
+		POSTGRES_DRIVER,
+		driver)
+	if err != nil {
+		return nil, fmt.Errorf("error: could not establish connection to the database: %v", err)
+	}
+
+	return migrator, nil
+}
+
+// RunMigrationUp applies upward database migrations to set up or update the database schema.
+//
+// Parameters:
+// - migrationPath: A string path to the migration files.
+//
+// Returns:
+// - An error if the migration fails; otherwise, nil.
+func (postgresStore *PostgresStore) RunMigrationUp(migrationPath string) error {
+	migrator, err := postgresStore.setUpMigration(migrationPath)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("error: could not run up migrations: %v", err)
+	}
+
+	return nil
+}
+
+// RunMigrationDown rolls back database migrations, effectively reverting changes to the database schema.
+//
+// Parameters:
+// - migrationPath: A string path to the migration files.
+//
+// Returns:
+// - An error if the migration rollback fails; otherwise, nil.
+func (postgresStore *PostgresStore) RunMigrationDown(migrationPath string) error {
+	migrator, err := postgresStore.setUpMigration(migrationPath)
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Down(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("error: run down migrations failed: %v", err)
+	}
+
+	return nil
+}
+
+// classifyPostgresError maps PostgreSQL server errors to the storage sentinel errors.
+//
+// Parameters:
+// - err: The error returned by the PostgreSQL driver.
+//
+// Returns:
+// - The matching sentinel error, or nil if the error is not recognized.
+func classifyPostgresError(err error) error {
+	var postgresErr *pq.Error
+	if !errors.As(err, &postgresErr) {
+		return nil
+	}
+
+	switch {
+	case postgresErr.Code == "23505", // unique_violation
+		postgresErr.Code == "23503": // foreign_key_violation
+		return ErrConflict
+	case postgresErr.Code.Class() == "08", // connection_exception
+		postgresErr.Code.Class() == "57", // operator_intervention (e.g., admin_shutdown)
+		postgresErr.Code == "53300":      // too_many_connections
+		return ErrUnavailable
+	}
+
+	return nil
+}
+
+// postgresFullTextMatch matches products through the GIN indexed searchVector column, which weighs
+// name terms above description terms, scoring them with ts_rank.
+//
+// Parameters:
+// - terms: The search terms, as returned by service.SearchTerms.
+//
+// Returns:
+// - The subquery selecting the productID and score of the matching products, and its parameters.
+func postgresFullTextMatch(terms []string) (string, []any) {
+	// The terms only contain letters and digits, so they never carry tsquery operators.
+	return `SELECT id AS productID, ts_rank(searchVector, query) AS score FROM products, to_tsquery('simple', ?) AS query WHERE searchVector @@ query`,
+		[]any{strings.Join(terms, " | ")}
+}
+
+// postgresAttributeText reads a product attribute from the JSONB attributes column with the ->> operator,
+// which renders strings unquoted and numbers and booleans in their JSON notation.
+//
+// Parameters:
+// - name: The attribute name, as accepted by service.IsValidAttributeName.
+//
+// Returns:
+// - The expression reading the attribute as text, and its parameters.
+func postgresAttributeText(name string) (string, []any) {
+	return `attributes ->> CAST(? AS TEXT)`, []any{name}
+}
@@ -0,0 +1,329 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"time"
+)
+
+// scheduledPriceColumns lists the scheduled_prices table columns in the order expected by scanIntoScheduledPrice.
+const scheduledPriceColumns = `id, productID, price, currency, effectiveAt, createdAt`
+
+// RetrievePriceHistory retrieves the recorded price changes of a product, the most recent first.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product.
+//
+// Returns:
+// - A slice of PriceChange pointers, empty if the price never changed, and nil if successful.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the retrieval fails.
+func (store *sqlStore) RetrievePriceHistory(ctx context.Context, id service.ProductID) ([]*service.PriceChange, error) {
+	if _, err := store.Retrieve(ctx, id); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT productID, previousPrice, previousCurrency, price, currency, changedAt FROM price_history WHERE productID = ? ORDER BY changedAt DESC, id DESC`
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), id)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var changes []*service.PriceChange
+	for rows.Next() {
+		change := new(service.PriceChange)
+		err := rows.Scan(
+			&change.ProductID,
+			&change.PreviousPrice,
+			&change.PreviousPrice.Currency,
+			&change.Price,
+			&change.Price.Currency,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, store.classify(err)
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return changes, nil
+}
+
+// SchedulePrice inserts a price change taking effect at the scheduled price's EffectiveAt and updates the
+// provided scheduled price with its generated ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - scheduledPrice: A pointer to the ScheduledPrice to store, updated with its ID.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the insertion fails; otherwise, nil.
+func (store *sqlStore) SchedulePrice(ctx context.Context, scheduledPrice *service.ScheduledPrice) error {
+	if _, err := store.Retrieve(ctx, scheduledPrice.ProductID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO scheduled_prices (productID, price, currency, effectiveAt, createdAt) VALUES (?, ?, ?, ?, ?)`
+	args := []any{
+		scheduledPrice.ProductID,
+		scheduledPrice.Price,
+		cmp.Or(scheduledPrice.Price.Currency, service.DefaultCurrency),
+		scheduledPrice.EffectiveAt,
+		scheduledPrice.CreatedAt,
+	}
+
+	id, err := store.insert(ctx, store.db, query, args...)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	scheduledPrice.ID = service.ScheduledPriceID(id)
+	return nil
+}
+
+// RetrieveScheduledPrices retrieves the pending price changes of a product, the earliest first.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product.
+//
+// Returns:
+// - A slice of ScheduledPrice pointers and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) RetrieveScheduledPrices(ctx context.Context, id service.ProductID) ([]*service.ScheduledPrice, error) {
+	query := `SELECT ` + scheduledPriceColumns + ` FROM scheduled_prices WHERE productID = ? ORDER BY effectiveAt, id`
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), id)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var scheduledPrices []*service.ScheduledPrice
+	for rows.Next() {
+		scheduledPrice, err := scanIntoScheduledPrice(rows)
+		if err != nil {
+			return nil, store.classify(err)
+		}
+
+		scheduledPrices = append(scheduledPrices, scheduledPrice)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return scheduledPrices, nil
+}
+
+// CancelScheduledPrice removes a pending price change of a product.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productID: The unique ProductID of the product.
+// - id: The unique ScheduledPriceID of the price change.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product has no such pending change, an error if the deletion fails; otherwise, nil.
+func (store *sqlStore) CancelScheduledPrice(ctx context.Context, productID service.ProductID, id service.ScheduledPriceID) error {
+	query := `DELETE FROM scheduled_prices WHERE id = ? AND productID = ?`
+	result, err := store.db.ExecContext(ctx, store.rebind(query), id, productID)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("error: scheduled price with id %d of product with id %d: %w", id, productID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// ApplyDuePrices applies every pending price change effective at or before the given time, the earliest first.
+// Each change is applied in its own transaction, which updates the product's price, increments its version,
+// records the change in the price history and removes the pending change, so concurrent schedulers
+// never apply the same change twice.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - now: The time up to which pending changes are due.
+//
+// Returns:
+// - The number of applied changes and nil if successful.
+// - The number of changes applied before the failure and an error if a change cannot be applied.
+func (store *sqlStore) ApplyDuePrices(ctx context.Context, now time.Time) (int, error) {
+	applied := 0
+	for {
+		due := false
+		err := store.inTransaction(ctx, func(tx *sql.Tx) error {
+			scheduledPrice, err := store.nextDuePrice(ctx, tx, now)
+			if err != nil || scheduledPrice == nil {
+				return err
+			}
+
+			due = true
+			return store.applyScheduledPrice(ctx, tx, scheduledPrice, now)
+		})
+		if err != nil {
+			return applied, err
+		}
+
+		if !due {
+			return applied, nil
+		}
+
+		applied++
+	}
+}
+
+// nextDuePrice reads the earliest pending price change effective at or before the given time within a transaction,
+// locking it until the transaction ends where the database supports locking reads.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the change is read in.
+// - now: The time up to which pending changes are due.
+//
+// Returns:
+// - A pointer to the due ScheduledPrice, or nil if no change is due, and nil if successful.
+// - An error if the read fails.
+func (store *sqlStore) nextDuePrice(ctx context.Context, tx *sql.Tx, now time.Time) (*service.ScheduledPrice, error) {
+	query := `SELECT ` + scheduledPriceColumns + ` FROM scheduled_prices WHERE effectiveAt <= ? ORDER BY effectiveAt, id LIMIT 1`
+	if store.dialect.lockingReads {
+		// Changes locked by another scheduler are left to it.
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	rows, err := tx.QueryContext(ctx, store.rebind(query), now)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		scheduledPrice, err := scanIntoScheduledPrice(rows)
+		return scheduledPrice, store.classify(err)
+	}
+
+	return nil, store.classify(rows.Err())
+}
+
+// applyScheduledPrice sets the product's price to the scheduled one, recording the change in the price history,
+// and removes the pending change.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the change is applied in.
+// - scheduledPrice: The due price change.
+// - now: The time the change is applied at, recorded as the product's last update.
+//
+// Returns:
+// - An error if the change cannot be applied; otherwise, nil.
+func (store *sqlStore) applyScheduledPrice(ctx context.Context, tx *sql.Tx, scheduledPrice *service.ScheduledPrice, now time.Time) error {
+	previousPrice, err := store.lockPrice(ctx, tx, scheduledPrice.ProductID)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE products SET price = ?, currency = ?, lastUpdated = ?, version = version + 1 WHERE id = ?`
+	_, err = tx.ExecContext(ctx, store.rebind(query), scheduledPrice.Price, scheduledPrice.Price.Currency, now, scheduledPrice.ProductID)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if err := store.recordPriceChange(ctx, tx, scheduledPrice.ProductID, previousPrice, scheduledPrice.Price, scheduledPrice.EffectiveAt); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, store.rebind(`DELETE FROM scheduled_prices WHERE id = ?`), scheduledPrice.ID)
+	return store.classify(err)
+}
+
+// lockPrice reads the current price of a product within a transaction, locking the product's row until the
+// transaction ends where the database supports locking reads.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the price is read in.
+// - id: The unique ProductID of the product.
+//
+// Returns:
+// - The current price of the product and nil if successful.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the read fails.
+func (store *sqlStore) lockPrice(ctx context.Context, tx *sql.Tx, id service.ProductID) (service.Money, error) {
+	query := `SELECT price, currency FROM products WHERE id = ?`
+	if store.dialect.lockingReads {
+		query += ` FOR UPDATE`
+	}
+
+	var price service.Money
+	err := tx.QueryRowContext(ctx, store.rebind(query), id).Scan(&price, &price.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.Money{}, fmt.Errorf("error: product with id %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return service.Money{}, store.classify(err)
+	}
+
+	return price, nil
+}
+
+// recordPriceChange inserts a change of a product's price into the price history, unless the price is unchanged.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the price was changed in.
+// - id: The unique ProductID of the product.
+// - previousPrice: The price before the change.
+// - price: The price after the change.
+// - changedAt: The time of the change.
+//
+// Returns:
+// - An error if the insertion fails; otherwise, nil.
+func (store *sqlStore) recordPriceChange(ctx context.Context, tx *sql.Tx, id service.ProductID, previousPrice, price service.Money, changedAt time.Time) error {
+	currency := cmp.Or(price.Currency, service.DefaultCurrency)
+	if previousPrice.Amount == price.Amount && previousPrice.Currency == currency {
+		return nil
+	}
+
+	query := `INSERT INTO price_history (productID, previousPrice, previousCurrency, price, currency, changedAt) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := tx.ExecContext(ctx, store.rebind(query), id, previousPrice, previousPrice.Currency, price, currency, changedAt)
+
+	return store.classify(err)
+}
+
+// scanIntoScheduledPrice scans the result rows into a ScheduledPrice instance.
+//
+// Parameters:
+// - rows: A pointer to sql.Rows containing the scheduled price data.
+//
+// Returns:
+// - A pointer to a populated ScheduledPrice instance and nil if successful.
+// - An error if scanning fails.
+func scanIntoScheduledPrice(rows *sql.Rows) (*service.ScheduledPrice, error) {
+	scheduledPrice := new(service.ScheduledPrice)
+	err := rows.Scan(
+		&scheduledPrice.ID,
+		&scheduledPrice.ProductID,
+		&scheduledPrice.Price,
+		&scheduledPrice.Price.Currency,
+		&scheduledPrice.EffectiveAt,
+		&scheduledPrice.CreatedAt,
+	)
+
+	return scheduledPrice, err
+}
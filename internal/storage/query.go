@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"slices"
+	"strings"
+)
+
+// sortColumns maps every sort key to the column it orders by.
+// Only the whitelisted columns ever reach the ORDER BY clauses, as they cannot be passed as query parameters.
+var sortColumns = map[service.SortKey]string{
+	service.SortByID:          "id",
+	service.SortByName:        "name",
+	service.SortByPrice:       "price",
+	service.SortByQuantity:    "quantity",
+	service.SortByCreatedAt:   "createdAt",
+	service.SortByLastUpdated: "lastUpdated",
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns, using "!" as the escape character.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// listingQuery accumulates the conditions and parameters of a product listing query.
+type listingQuery struct {
+	conditions []string
+	args       []any
+}
+
+// newListingQuery creates the listing query selecting the products matching the filter.
+//
+// Parameters:
+// - filter: The criteria the listed products must match.
+// - dialect: The dialect of the store, which reads the product attributes.
+//
+// Returns:
+// - A pointer to the listingQuery holding the filter conditions.
+func newListingQuery(filter *service.ProductFilter, dialect dialect) *listingQuery {
+	listing := new(listingQuery)
+
+	if filter.NameContains != "" {
+		listing.where(`LOWER(name) LIKE LOWER(?) ESCAPE '!'`, "%"+likeEscaper.Replace(filter.NameContains)+"%")
+	}
+	if filter.MinPrice != nil {
+		listing.where(`price >= ?`, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		listing.where(`price <= ?`, *filter.MaxPrice)
+	}
+	if filter.InStock != nil {
+		if *filter.InStock {
+			listing.where(`quantity > 0`)
+		} else {
+			listing.where(`quantity <= 0`)
+		}
+	}
+	if len(filter.CategoryIDs) > 0 {
+		args := make([]any, len(filter.CategoryIDs))
+		for i, categoryID := range filter.CategoryIDs {
+			args[i] = categoryID
+		}
+
+		listing.where(`id IN (SELECT productID FROM product_categories WHERE categoryID IN (`+placeholders(len(args))+`))`, args...)
+	}
+	if tags := service.NormalizeTags(filter.Tags); len(tags) > 0 {
+		args := make([]any, len(tags))
+		for i, tag := range tags {
+			args[i] = tag
+		}
+
+		condition := `id IN (SELECT productID FROM product_tags WHERE tag IN (` + placeholders(len(args)) + `)`
+		if !filter.MatchAnyTag {
+			// Every tag is carried at most once per product, so carrying all of them means matching each.
+			condition += ` GROUP BY productID HAVING COUNT(*) = ?`
+			args = append(args, len(tags))
+		}
+
+		listing.where(condition+`)`, args...)
+	}
+	if len(filter.Attributes) > 0 {
+		names := make([]string, 0, len(filter.Attributes))
+		for name := range filter.Attributes {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		for _, name := range names {
+			attribute, args := dialect.attributeText(name)
+			listing.where(attribute+` = ?`, append(args, filter.Attributes[name])...)
+		}
+	}
+
+	return listing
+}
+
+// placeholders returns a comma separated list of count ? placeholders, for IN clauses.
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// where adds a condition, along with its parameters, that every listed product must satisfy.
+func (listing *listingQuery) where(condition string, args ...any) {
+	listing.conditions = append(listing.conditions, condition)
+	listing.args = append(listing.args, args...)
+}
+
+// after restricts the listing to the products following the cursor in the given order.
+// For the order (a ASC, b DESC, id ASC) the condition is expanded to
+// (a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?), which every database supports.
+//
+// Parameters:
+// - cursor: The cursor marking the last product of the previous page.
+// - orderBy: The order of the listing, as returned by ProductQuery.OrderBy.
+//
+// Returns:
+// - An error if the cursor does not match the order or the order contains an unknown sort key; otherwise, nil.
+func (listing *listingQuery) after(cursor *service.Cursor, orderBy []service.SortField) error {
+	if len(cursor.Values) != len(orderBy) {
+		return fmt.Errorf("error: cursor does not match the sort order")
+	}
+
+	var alternatives []string
+	var args []any
+
+	for i, field := range orderBy {
+		column, ok := sortColumns[field.Key]
+		if !ok {
+			return fmt.Errorf("error: unknown sort key %q", field.Key)
+		}
+
+		var terms []string
+		for j := range i {
+			terms = append(terms, sortColumns[orderBy[j].Key]+` = ?`)
+			args = append(args, cursor.Values[j])
+		}
+
+		operator := ` > ?`
+		if field.Descending {
+			operator = ` < ?`
+		}
+		terms = append(terms, column+operator)
+		args = append(args, cursor.Values[i])
+
+		alternatives = append(alternatives, `(`+strings.Join(terms, ` AND `)+`)`)
+	}
+
+	listing.where(`(`+strings.Join(alternatives, ` OR `)+`)`, args...)
+	return nil
+}
+
+// whereClause returns the WHERE clause combining the conditions, or an empty string if there are none.
+func (listing *listingQuery) whereClause() string {
+	if len(listing.conditions) == 0 {
+		return ""
+	}
+
+	return ` WHERE ` + strings.Join(listing.conditions, ` AND `)
+}
+
+// orderByClause builds the ORDER BY clause of the listing from the whitelisted sort columns.
+//
+// Parameters:
+// - orderBy: The order of the listing, as returned by ProductQuery.OrderBy.
+//
+// Returns:
+// - The ORDER BY clause and nil if successful.
+// - An error if the order contains an unknown sort key.
+func orderByClause(orderBy []service.SortField) (string, error) {
+	var columns []string
+
+	for _, field := range orderBy {
+		column, ok := sortColumns[field.Key]
+		if !ok {
+			return "", fmt.Errorf("error: unknown sort key %q", field.Key)
+		}
+
+		if field.Descending {
+			column += ` DESC`
+		}
+		columns = append(columns, column)
+	}
+
+	return ` ORDER BY ` + strings.Join(columns, `, `), nil
+}
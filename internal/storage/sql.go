@@ -0,0 +1,709 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// productColumns lists the products table columns in the order expected by scanIntoProduct.
+const productColumns = `id, sku, name, description, price, currency, discount, quantity, createdAt, lastUpdated, version, attributes`
+
+// dialect describes the differences between the SQL databases supported by sqlStore.
+type dialect struct {
+	numberedPlaceholders bool              // numberedPlaceholders indicates that bind parameters are written as $1, $2, ... instead of ?.
+	insertReturning      bool              // insertReturning indicates that generated IDs are read through an INSERT ... RETURNING clause.
+	classifyDriverError  func(error) error // classifyDriverError maps driver specific errors to the storage sentinel errors.
+	lockingReads         bool              // lockingReads indicates that SELECT ... FOR UPDATE locks the read rows, while other databases serialize transactions.
+	onDuplicateKey       bool              // onDuplicateKey indicates that upserts are written as ON DUPLICATE KEY UPDATE instead of ON CONFLICT.
+
+	// fullTextMatch builds the subquery selecting the productID and relevance score of every product whose name
+	// or description matches any of the search terms, along with its parameters.
+	fullTextMatch func(terms []string) (string, []any)
+
+	// attributeText builds the expression reading the named product attribute as the text service.AttributeText
+	// returns for its value, NULL if the product lacks the attribute, along with its parameters.
+	attributeText func(name string) (string, []any)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx, so statements can run inside or outside of transactions.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlStore implements the product CRUD operations shared by every database/sql backed ProductStore.
+// Queries are written with ? placeholders and rewritten according to the store's dialect.
+type sqlStore struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// Create inserts a new product, along with its tags, into the database within a single transaction and updates
+// the provided product reference with the newly created product’s details.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - product: A double pointer to a Product instance, updated with additional data.
+//
+// Returns:
+// - An error wrapping ErrConflict if another product has the same SKU, or an error if the insertion fails; otherwise, nil.
+func (store *sqlStore) Create(ctx context.Context, product **service.Product) error {
+	query := `INSERT INTO products (sku, name, description, price, currency, discount, quantity, createdAt, lastUpdated, attributes) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	args := []any{
+		(*product).SKU,
+		(*product).Name,
+		(*product).Description,
+		(*product).Price,
+		cmp.Or((*product).Price.Currency, service.DefaultCurrency),
+		(*product).Discount,
+		(*product).Quantity,
+		(*product).CreatedAt,
+		(*product).LastUpdated,
+		(*product).Attributes,
+	}
+
+	var productID int64
+	err := store.inTransaction(ctx, func(tx *sql.Tx) error {
+		var err error
+		if productID, err = store.insert(ctx, tx, query, args...); err != nil {
+			return store.classify(err)
+		}
+
+		return store.saveTags(ctx, tx, service.ProductID(productID), (*product).Tags)
+	})
+	if err != nil {
+		return err
+	}
+
+	*product, err = store.Retrieve(ctx, service.ProductID(productID))
+	if err != nil {
+		return fmt.Errorf("error: could not retrieve newly created product: %w", err)
+	}
+
+	return nil
+}
+
+// RetrieveAll retrieves a paginated list of the products matching the query's filter, in the query's order.
+// Keyset paginated queries continue after the cursor's product instead of skipping rows, so deep pages stay fast.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productQuery: The filter, order, page, by number or cursor, and limit of products to retrieve; invalid page numbers and limits fall back to their defaults.
+//
+// Returns:
+// - A slice of Product pointers and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) RetrieveAll(ctx context.Context, productQuery *service.ProductQuery) ([]*service.Product, error) {
+	page := *productQuery
+	page.Normalize()
+
+	orderBy := page.OrderBy()
+	listing := newListingQuery(&page.Filter, store.dialect)
+
+	if page.After != nil {
+		if err := listing.after(page.After, orderBy); err != nil {
+			return nil, err
+		}
+	}
+
+	orderByClause, err := orderByClause(orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + productColumns + ` FROM products` + listing.whereClause() + orderByClause + ` LIMIT ? OFFSET ?`
+	args := append(listing.args, page.Limit, page.Offset())
+
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var products []*service.Product
+	for rows.Next() {
+		product, err := scanIntoProduct(rows)
+		if err != nil {
+			return nil, store.classify(err)
+		}
+
+		products = append(products, product)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+	rows.Close()
+
+	if err := store.loadTags(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// Count returns the number of products in the database matching the query's filter, regardless of its pagination.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productQuery: The query of the listing; its order, page, cursor and limit are ignored.
+//
+// Returns:
+// - The number of products and nil if successful.
+// - An error if the count fails.
+func (store *sqlStore) Count(ctx context.Context, productQuery *service.ProductQuery) (int, error) {
+	var count int
+	listing := newListingQuery(&productQuery.Filter, store.dialect)
+
+	err := store.db.QueryRowContext(ctx, store.rebind(`SELECT COUNT(*) FROM products`+listing.whereClause()), listing.args...).Scan(&count)
+	if err != nil {
+		return 0, store.classify(err)
+	}
+
+	return count, nil
+}
+
+// Search retrieves a page of the products whose name or description matches any term of the search text,
+// ordered by decreasing relevance, ties being broken by ID. The matching and scoring are delegated to the
+// full-text index of the database, as described by the store's dialect.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - searchQuery: The search text, page and limit of results to retrieve; invalid page numbers and limits fall back to their defaults.
+//
+// Returns:
+// - A slice of SearchHit pointers, empty if the text contains no terms, and nil if successful.
+// - An error if the search fails.
+func (store *sqlStore) Search(ctx context.Context, searchQuery *service.SearchQuery) ([]*service.SearchHit, error) {
+	page := *searchQuery
+	page.Normalize()
+
+	terms := service.SearchTerms(page.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	match, args := store.dialect.fullTextMatch(terms)
+	query := `SELECT ` + productColumns + `, matches.score FROM products JOIN (` + match + `) AS matches ON matches.productID = products.id` +
+		` ORDER BY matches.score DESC, products.id LIMIT ? OFFSET ?`
+	args = append(args, page.Limit, page.Offset())
+
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var hits []*service.SearchHit
+	for rows.Next() {
+		hit := new(service.SearchHit)
+		if hit.Product, err = scanIntoProduct(rows, &hit.Score); err != nil {
+			return nil, store.classify(err)
+		}
+
+		hits = append(hits, hit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+	rows.Close()
+
+	products := make([]*service.Product, len(hits))
+	for i, hit := range hits {
+		products[i] = hit.Product
+	}
+
+	if err := store.loadTags(ctx, products); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// Retrieve fetches a product by its unique ID from the database.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product to retrieve.
+//
+// Returns:
+// - A pointer to the retrieved Product and nil if successful.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the retrieval fails.
+func (store *sqlStore) Retrieve(ctx context.Context, id service.ProductID) (*service.Product, error) {
+	product, err := store.retrieveWhere(ctx, `id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, fmt.Errorf("error: product with id %d: %w", id, ErrNotFound)
+	}
+
+	return product, nil
+}
+
+// RetrieveBySKU fetches a product by its unique SKU from the database.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - sku: The unique SKU of the product to retrieve.
+//
+// Returns:
+// - A pointer to the retrieved Product and nil if successful.
+// - An error wrapping ErrNotFound if no product has the SKU, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveBySKU(ctx context.Context, sku string) (*service.Product, error) {
+	product, err := store.retrieveWhere(ctx, `sku = ?`, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil {
+		return nil, fmt.Errorf("error: product with sku %q: %w", sku, ErrNotFound)
+	}
+
+	return product, nil
+}
+
+// retrieveWhere fetches the single product matching the condition, along with its tags.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - condition: The WHERE condition, written with a single ? placeholder, matching at most one product.
+// - arg: The value bound to the placeholder.
+//
+// Returns:
+// - A pointer to the retrieved Product, or nil if no product matches, and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) retrieveWhere(ctx context.Context, condition string, arg any) (*service.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE ` + condition
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), arg)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var product *service.Product
+	if rows.Next() {
+		if product, err = scanIntoProduct(rows); err != nil {
+			return nil, store.classify(err)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+	rows.Close()
+
+	if product == nil {
+		return nil, nil
+	}
+
+	if err := store.loadTags(ctx, []*service.Product{product}); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Update modifies an existing product’s details in the database and increments its version.
+// A changed price is recorded in the price history and the tags are replaced, within the same transaction as the update,
+// which also checks the attributes against the schemas of the product's categories.
+// The quantity delta is applied atomically and only if the stock stays non-negative.
+// A non-zero Version makes the update conditional on the stored version (compare-and-swap).
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - product: A double pointer to the Product instance containing the updated details.
+//
+// Returns:
+// - An *InsufficientStockError if the quantity delta exceeds the available stock.
+// - An error wrapping ErrVersionMismatch if the stored version differs from the product's version.
+// - A NonconformingAttributesError if the attributes do not conform to the schemas of the product's categories.
+// - An error wrapping ErrConflict if another product has the same SKU.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the update fails; otherwise, nil.
+func (store *sqlStore) Update(ctx context.Context, product **service.Product) error {
+	err := store.inTransaction(ctx, func(tx *sql.Tx) error {
+		previousPrice, err := store.lockPrice(ctx, tx, (*product).ID)
+		if err != nil {
+			return err
+		}
+
+		// Atomic increment of quantity field
+		query := `UPDATE products SET sku = ?, name = ?, description = ?, price = ?, currency = ?, discount = ?, quantity = quantity + ?, lastUpdated = ?, attributes = ?, version = version + 1 WHERE id = ? AND quantity + ? >= 0`
+		args := []any{
+			(*product).SKU,
+			(*product).Name,
+			(*product).Description,
+			(*product).Price,
+			cmp.Or((*product).Price.Currency, service.DefaultCurrency),
+			(*product).Discount,
+			(*product).GetQuantityDelta(),
+			(*product).LastUpdated,
+			(*product).Attributes,
+			(*product).ID,
+			(*product).GetQuantityDelta(),
+		}
+
+		if (*product).Version != 0 {
+			query += ` AND version = ?`
+			args = append(args, (*product).Version)
+		}
+
+		result, err := tx.ExecContext(ctx, store.rebind(query), args...)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if rowsAffected > 1 {
+			return fmt.Errorf("error: more than one rows were affected. rows affected: %d", rowsAffected)
+		}
+
+		if rowsAffected == 0 {
+			return store.explainSkippedWrite(ctx, tx, (*product).ID, (*product).Version, (*product).GetQuantityDelta())
+		}
+
+		categories, err := store.queryCategories(ctx, tx, productCategoriesQuery, (*product).ID)
+		if err != nil {
+			return err
+		}
+
+		if attributeErrs := service.CheckAttributes((*product).Attributes, categories); attributeErrs != nil {
+			return &NonconformingAttributesError{ProductID: (*product).ID, Errors: attributeErrs}
+		}
+
+		if err := store.recordPriceChange(ctx, tx, (*product).ID, previousPrice, (*product).Price, (*product).LastUpdated); err != nil {
+			return err
+		}
+
+		return store.saveTags(ctx, tx, (*product).ID, (*product).Tags)
+	})
+	if err != nil {
+		return err
+	}
+
+	*product, err = store.Retrieve(ctx, (*product).ID)
+	if err != nil {
+		return fmt.Errorf("error: could not retrieve updated product: %w", err)
+
+	}
+
+	return nil
+}
+
+// AdjustStock atomically adds a signed delta to a product's quantity, provided the stock stays non-negative,
+// and increments the product's version, within a transaction holding the product row.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - id: The unique ProductID of the product to adjust.
+// - quantityDelta: The number of units to add (positive) or remove (negative).
+//
+// Returns:
+// - A pointer to the adjusted Product and nil if successful.
+// - An *InsufficientStockError if the delta exceeds the available stock.
+// - An error wrapping ErrConflict if the adjustment was skipped for any other reason.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the adjustment fails.
+func (store *sqlStore) AdjustStock(ctx context.Context, id service.ProductID, quantityDelta int) (*service.Product, error) {
+	err := store.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := store.lockPrice(ctx, tx, id); err != nil {
+			return err
+		}
+
+		query := `UPDATE products SET quantity = quantity + ?, lastUpdated = ?, version = version + 1 WHERE id = ? AND quantity + ? >= 0`
+		result, err := tx.ExecContext(ctx, store.rebind(query), quantityDelta, time.Now().UTC(), id, quantityDelta)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if rowsAffected == 0 {
+			return store.explainSkippedWrite(ctx, tx, id, 0, quantityDelta)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := store.Retrieve(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error: could not retrieve adjusted product: %w", err)
+	}
+
+	return product, nil
+}
+
+// Delete removes a product from the database.
+// A non-zero Version makes the deletion conditional on the stored version.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - product: A pointer to the Product instance to delete.
+//
+// Returns:
+// - An error wrapping ErrVersionMismatch if the stored version differs from the product's version.
+// - An error wrapping ErrNotFound if the product does not exist, an error if the deletion fails; otherwise, nil.
+func (store *sqlStore) Delete(ctx context.Context, product *service.Product) error {
+	return store.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := store.lockPrice(ctx, tx, product.ID); err != nil {
+			return err
+		}
+
+		query := `DELETE FROM products WHERE id = ?`
+		args := []any{product.ID}
+
+		if product.Version != 0 {
+			query += ` AND version = ?`
+			args = append(args, product.Version)
+		}
+
+		result, err := tx.ExecContext(ctx, store.rebind(query), args...)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if rowsAffected == 0 {
+			return store.explainSkippedWrite(ctx, tx, product.ID, product.Version, 0)
+		}
+
+		return nil
+	})
+}
+
+// explainSkippedWrite explains why a guarded write affected no rows. Run within the transaction of the write,
+// after the product row was locked, it sees the row exactly as the write did.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - db: The connection pool or transaction the write ran in.
+// - id: The ID of the written product.
+// - version: The version the write was conditioned on, or zero if unconditional.
+// - quantityDelta: The quantity change the write attempted to apply.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product does not exist.
+// - An error wrapping ErrVersionMismatch if the stored version differs from the expected one.
+// - An *InsufficientStockError if the quantity change exceeds the available stock.
+// - An error wrapping ErrConflict if none of the guards explains the skipped write, as a skipped write is never a success.
+func (store *sqlStore) explainSkippedWrite(ctx context.Context, db queryer, id service.ProductID, version int64, quantityDelta int) error {
+	var available int
+	var storedVersion int64
+	err := db.QueryRowContext(ctx, store.rebind(`SELECT quantity, version FROM products WHERE id = ?`), id).Scan(&available, &storedVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("error: product with id %d: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if version != 0 && version != storedVersion {
+		return fmt.Errorf("error: product with id %d: %w: expected %d, stored %d", id, ErrVersionMismatch, version, storedVersion)
+	}
+
+	if available+quantityDelta < 0 {
+		return &InsufficientStockError{ProductID: id, Requested: -quantityDelta, Available: available}
+	}
+
+	return fmt.Errorf("error: product with id %d: write skipped by a concurrent change: %w", id, ErrConflict)
+}
+
+// VerifyStoreConnection verifies that the store connection is active and operational.
+//
+// Parameters:
+// - ctx: The context bounding the duration of the check.
+//
+// Returns:
+// - An error wrapping ErrUnavailable if the connection cannot be established; otherwise, nil.
+func (store *sqlStore) VerifyStoreConnection(ctx context.Context) error {
+	err := store.db.PingContext(ctx)
+	if err != nil {
+		return fmt.Errorf("error: could not establish connection to the storage: %w: %v", ErrUnavailable, err)
+	}
+
+	return nil
+}
+
+// MigrationVersion reads the migration state recorded by golang-migrate in the schema_migrations table.
+//
+// Parameters:
+// - ctx: The context bounding the duration of the lookup.
+//
+// Returns:
+// - The version of the last applied migration, or 0 if no migration has been applied.
+// - True if the last migration failed midway and left the schema dirty; otherwise, false.
+// - An error if the migration state cannot be read; otherwise, nil.
+func (store *sqlStore) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version uint
+	var dirty bool
+
+	err := store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error: could not read migration version: %w", store.classify(err))
+	}
+
+	return version, dirty, nil
+}
+
+// Close terminates the store connection, releasing resources.
+//
+// Returns:
+// - An error if the closure fails; otherwise, nil.
+func (store *sqlStore) Close() error {
+	return store.db.Close()
+}
+
+// inTransaction runs fn within a database transaction, committing it if fn succeeds and rolling it back otherwise.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the transaction.
+// - fn: The operations to run, which must use the provided transaction instead of the store's connection pool.
+//
+// Returns:
+// - The error returned by fn, or an error if the transaction cannot be started or committed; otherwise, nil.
+func (store *sqlStore) inTransaction(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.classify(err)
+	}
+
+	return nil
+}
+
+// insert executes an INSERT statement and returns the ID generated for the new row.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - db: The connection pool or transaction the statement runs in.
+// - query: The INSERT statement, written with ? placeholders.
+// - args: The values bound to the statement placeholders.
+//
+// Returns:
+// - The generated ID and nil if successful.
+// - An error if the insertion fails or the ID cannot be determined.
+func (store *sqlStore) insert(ctx context.Context, db queryer, query string, args ...any) (int64, error) {
+	if store.dialect.insertReturning {
+		var id int64
+		if err := db.QueryRowContext(ctx, store.rebind(query+` RETURNING id`), args...).Scan(&id); err != nil {
+			return 0, err
+		}
+
+		return id, nil
+	}
+
+	result, err := db.ExecContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("error: could not retrieve last inserted ID: %v", err)
+	}
+
+	return id, nil
+}
+
+// classify wraps a database error with the storage sentinel error describing it.
+//
+// Parameters:
+// - err: The error returned by the database driver.
+//
+// Returns:
+// - The classified error, or nil if err is nil.
+func (store *sqlStore) classify(err error) error {
+	return classifyError(err, store.dialect.classifyDriverError)
+}
+
+// rebind rewrites the ? placeholders of a query into the placeholder style of the store's dialect.
+//
+// Parameters:
+// - query: The query written with ? placeholders.
+//
+// Returns:
+// - The query using the dialect's placeholder style.
+func (store *sqlStore) rebind(query string) string {
+	if !store.dialect.numberedPlaceholders {
+		return query
+	}
+
+	var builder strings.Builder
+	position := 0
+	for _, char := range query {
+		if char == '?' {
+			position++
+			builder.WriteString("$" + strconv.Itoa(position))
+			continue
+		}
+
+		builder.WriteRune(char)
+	}
+
+	return builder.String()
+}
+
+// scanIntoProduct scans the result rows into a Product instance.
+//
+// Parameters:
+// - rows: A pointer to sql.Rows containing the product data.
+// - extra: The destinations of any columns selected after the product columns.
+//
+// Returns:
+// - A pointer to a populated Product instance and nil if successful.
+// - An error if scanning fails.
+func scanIntoProduct(rows *sql.Rows, extra ...any) (*service.Product, error) {
+	product := new(service.Product)
+	destinations := []any{
+		&product.ID,
+		&product.SKU,
+		&product.Name,
+		&product.Description,
+		&product.Price,
+		&product.Price.Currency,
+		&product.Discount,
+		&product.Quantity,
+		&product.CreatedAt,
+		&product.LastUpdated,
+		&product.Version,
+		&product.Attributes,
+	}
+
+	err := rows.Scan(append(destinations, extra...)...)
+
+	return product, err
+}
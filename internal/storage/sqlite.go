@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"ntsiris/product-microservice/internal/config"
+	"ntsiris/product-microservice/migrations"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	sqliteMigrate "github.com/golang-migrate/migrate/v4/database/sqlite" // SQLite driver
+	"github.com/golang-migrate/migrate/v4/source/iofs"                   // Embedded source driver
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore is a struct that provides methods for interacting with an embedded SQLite database.
+// It supports CRUD operations, provided by the embedded sqlStore, and schema migrations for product data.
+// The database lives either in a file or, when configured with ":memory:", entirely in memory.
+type SQLiteStore struct {
+	sqlStore
+	dbURL string
+}
+
+// SQLITE_DRIVER is a constant that specifies the database driver used for SQLite.
+const SQLITE_DRIVER string = "sqlite"
+
+// SQLITE_IN_MEMORY is the database name that keeps the SQLite database in memory.
+const SQLITE_IN_MEMORY string = ":memory:"
+
+// InitStore initializes the SQLite store connection using the provided StorageConfig.
+// Only the Name setting is used, as the path of the database file or ":memory:".
+//
+// Parameters:
+// - config: A pointer to a StorageConfig struct containing database connection settings.
+//
+// Returns:
+// - An error if the connection initialization fails; otherwise, nil.
+func (sqliteStore *SQLiteStore) InitStore(config *config.StorageConfig) error {
+	sqliteURL := url.URL{
+		Scheme:   "file",
+		Opaque:   config.Name,
+		RawQuery: url.Values{"_pragma": {"foreign_keys(1)", "busy_timeout(5000)"}, "_time_format": {"sqlite"}}.Encode(),
+	}
+
+	sqliteStore.dbURL = sqliteURL.String()
+	db, err := sql.Open(SQLITE_DRIVER, sqliteStore.dbURL)
+
+	if err != nil {
+		return fmt.Errorf("error: could not acquire storage connection handle: %v", err)
+	}
+
+	// SQLite serializes writers and every connection to ":memory:" opens a distinct database,
+	// so all operations share a single connection.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	sqliteStore.sqlStore = sqlStore{db: db, dialect: dialect{insertReturning: true, classifyDriverError: classifySQLiteError, fullTextMatch: sqliteFullTextMatch, attributeText: sqliteAttributeText}}
+
+	return nil
+}
+
+func (sqliteStore *SQLiteStore) setUpMigration() (*migrate.Migrate, error) {
+	driver, err := sqliteMigrate.WithInstance(sqliteStore.db, &sqliteMigrate.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("error: could not initialize migration driver: %v", err)
+	}
+
+	source, err := iofs.New(migrations.SQLite, SQLITE_DRIVER)
+	if err != nil {
+		return nil, fmt.Errorf("error: could not load embedded migrations: %v", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("iofs", source, SQLITE_DRIVER, driver)
+	if err != nil {
+		return nil, fmt.Errorf("error: could not establish connection to the database: %v", err)
+	}
+
+	return migrator, nil
+}
+
+// RunMigrationUp applies upward database migrations to set up or update the database schema.
+// The SQLite migrations are embedded into the binary, so the migration path is ignored.
+//
+// Parameters:
+// - migrationPath: A string path to the migration files (unused).
+//
+// Returns:
+// - An error if the migration fails; otherwise, nil.
+func (sqliteStore *SQLiteStore) RunMigrationUp(migrationPath string) error {
+	migrator, err := sqliteStore.setUpMigration()
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("error: could not run up migrations: %v", err)
+	}
+
+	return nil
+}
+
+// RunMigrationDown rolls back database migrations, effectively reverting changes to the database schema.
+// The SQLite migrations are embedded into the binary, so the migration path is ignored.
+//
+// Parameters:
+// - migrationPath: A string path to the migration files (unused).
+//
+// Returns:
+// - An error if the migration rollback fails; otherwise, nil.
+func (sqliteStore *SQLiteStore) RunMigrationDown(migrationPath string) error {
+	migrator, err := sqliteStore.setUpMigration()
+	if err != nil {
+		return err
+	}
+
+	if err := migrator.Down(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("error: run down migrations failed: %v", err)
+	}
+
+	return nil
+}
+
+// classifySQLiteError maps SQLite result codes to the storage sentinel errors.
+//
+// Parameters:
+// - err: The error returned by the SQLite driver.
+//
+// Returns:
+// - The matching sentinel error, or nil if the error is not recognized.
+func classifySQLiteError(err error) error {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return nil
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return ErrConflict
+	}
+
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return ErrUnavailable
+	}
+
+	return nil
+}
+
+// sqliteFullTextMatch matches products through the products_search FTS5 table, kept in sync with the products
+// table by triggers, scoring them with the negated BM25 rank, where name terms weigh ten times description terms.
+//
+// Parameters:
+// - terms: The search terms, as returned by service.SearchTerms.
+//
+// Returns:
+// - The subquery selecting the productID and score of the matching products, and its parameters.
+func sqliteFullTextMatch(terms []string) (string, []any) {
+	var phrases []string
+	for _, term := range terms {
+		// The terms only contain letters and digits, so quoting them escapes the FTS5 query syntax.
+		phrases = append(phrases, `"`+term+`"`)
+	}
+
+	return `SELECT rowid AS productID, -bm25(products_search, 10.0, 1.0) AS score FROM products_search WHERE products_search MATCH ?`,
+		[]any{strings.Join(phrases, " OR ")}
+}
+
+// sqliteAttributeText reads a product attribute from the JSON text of the attributes column.
+// json_extract returns booleans as integers, so they are told apart by their JSON type.
+//
+// Parameters:
+// - name: The attribute name, as accepted by service.IsValidAttributeName.
+//
+// Returns:
+// - The expression reading the attribute as text, and its parameters.
+func sqliteAttributeText(name string) (string, []any) {
+	// The name only contains letters, digits, underscores and hyphens, so quoting it escapes the JSON path syntax.
+	path := `$."` + name + `"`
+
+	return `CASE json_type(attributes, ?) WHEN 'true' THEN 'true' WHEN 'false' THEN 'false' ELSE CAST(json_extract(attributes, ?) AS TEXT) END`,
+		[]any{path, path}
+}
@@ -0,0 +1,54 @@
+package storage_test
+
+import (
+	"context"
+	"ntsiris/product-microservice/internal/config"
+	"ntsiris/product-microservice/internal/storage"
+	"ntsiris/product-microservice/internal/storage/storagetest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSQLiteStore(t *testing.T) storage.ProductStore {
+	store := &storage.SQLiteStore{}
+	require.NoError(t, store.InitStore(&config.StorageConfig{Driver: storage.SQLITE_DRIVER, Name: storage.SQLITE_IN_MEMORY}))
+	require.NoError(t, store.RunMigrationUp(""))
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestSQLiteStore(t *testing.T) {
+	storagetest.Run(t, newSQLiteStore)
+}
+
+func TestSQLiteStoreMigrationVersion(t *testing.T) {
+	store := &storage.SQLiteStore{}
+	require.NoError(t, store.InitStore(&config.StorageConfig{Driver: storage.SQLITE_DRIVER, Name: storage.SQLITE_IN_MEMORY}))
+	t.Cleanup(func() { store.Close() })
+
+	t.Run("fails before the migration table exists", func(t *testing.T) {
+		_, _, err := store.MigrationVersion(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("reports the last applied migration", func(t *testing.T) {
+		require.NoError(t, store.RunMigrationUp(""))
+
+		version, dirty, err := store.MigrationVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, uint(16), version)
+		assert.False(t, dirty)
+	})
+
+	t.Run("reports no migration after rolling back", func(t *testing.T) {
+		require.NoError(t, store.RunMigrationDown(""))
+
+		version, dirty, err := store.MigrationVersion(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, version)
+		assert.False(t, dirty)
+	})
+}
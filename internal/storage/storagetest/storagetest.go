@@ -0,0 +1,1632 @@
+// Package storagetest provides a conformance test suite that every storage.ProductStore implementation must pass.
+//
+// Implementations run the suite from their own tests, supplying a factory that returns an empty, ready to use store:
+//
+//	func TestSQLiteStore(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storage.ProductStore {
+//			return newEmptySQLiteStore(t)
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"ntsiris/product-microservice/internal/storage"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// StoreFactory creates an empty ProductStore for a single test.
+// The factory is responsible for releasing the store once the test finishes (e.g., with t.Cleanup).
+type StoreFactory func(t *testing.T) storage.ProductStore
+
+// timestampTolerance accounts for stores that persist timestamps with second precision.
+const timestampTolerance = 2 * time.Second
+
+// Run executes the conformance suite against the stores created by newStore.
+// Every sub-test receives a fresh store, so implementations are checked in isolation.
+//
+// Parameters:
+// - t: The parent test.
+// - newStore: The factory creating an empty store for every sub-test.
+func Run(t *testing.T, newStore StoreFactory) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, newStore(t)) })
+	t.Run("Retrieve", func(t *testing.T) { testRetrieve(t, newStore(t)) })
+	t.Run("SKU", func(t *testing.T) { testSKU(t, newStore(t)) })
+	t.Run("RetrieveAll", func(t *testing.T) { testRetrieveAll(t, newStore(t)) })
+	t.Run("FilterAndSort", func(t *testing.T) { testFilterAndSort(t, newStore(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newStore(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStore(t)) })
+	t.Run("AdjustStock", func(t *testing.T) { testAdjustStock(t, newStore(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore(t)) })
+	t.Run("ExchangeRates", func(t *testing.T) { testExchangeRates(t, newStore(t)) })
+	t.Run("PriceHistory", func(t *testing.T) { testPriceHistory(t, newStore(t)) })
+	t.Run("ScheduledPrices", func(t *testing.T) { testScheduledPrices(t, newStore(t)) })
+	t.Run("Categories", func(t *testing.T) { testCategories(t, newStore(t)) })
+	t.Run("ProductCategories", func(t *testing.T) { testProductCategories(t, newStore(t)) })
+	t.Run("Tags", func(t *testing.T) { testTags(t, newStore(t)) })
+	t.Run("Variants", func(t *testing.T) { testVariants(t, newStore(t)) })
+	t.Run("Attributes", func(t *testing.T) { testAttributes(t, newStore(t)) })
+	t.Run("CanceledContext", func(t *testing.T) { testCanceledContext(t, newStore(t)) })
+}
+
+// CreateProduct stores a new product built from the payload, failing the test if the creation fails.
+//
+// Parameters:
+// - t: The running test.
+// - store: The store the product is created in.
+// - payload: The creation details of the product.
+//
+// Returns:
+// - A pointer to the created Product, as returned by the store.
+func CreateProduct(t *testing.T, store storage.ProductStore, payload *service.ProductCreationPayload) *service.Product {
+	t.Helper()
+	ctx := context.Background()
+
+	product := service.NewProduct(payload)
+	require.NoError(t, store.Create(ctx, &product))
+
+	return product
+}
+
+// skuSequence numbers the SKUs of the payloads returned by newPayload, keeping them unique.
+var skuSequence atomic.Int64
+
+// newPayload returns a valid creation payload for a product with the given name and quantity, and a unique SKU.
+func newPayload(name string, quantity int) *service.ProductCreationPayload {
+	return &service.ProductCreationPayload{
+		SKU:         fmt.Sprintf("SKU-%d", skuSequence.Add(1)),
+		Price:       amount(1999),
+		Quantity:    quantity,
+		Discount:    5,
+		Name:        name,
+		Description: fmt.Sprintf("%s description", name),
+	}
+}
+
+func testCreate(t *testing.T, store storage.ProductStore) {
+	t.Run("assigns an ID and persists every field", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Created Product", 10))
+
+		assert.NotZero(t, product.ID)
+		assert.NotEmpty(t, product.SKU)
+		assert.Equal(t, "Created Product", product.Name)
+		assert.Equal(t, "Created Product description", product.Description)
+		assert.Equal(t, amount(1999), product.Price)
+		assert.Equal(t, float32(5), product.Discount)
+		assert.Equal(t, 10, product.Quantity)
+	})
+
+	t.Run("persists the currency of the price", func(t *testing.T) {
+		payload := newPayload("Dollar Product", 1)
+		payload.Currency = "USD"
+		product := CreateProduct(t, store, payload)
+
+		assert.Equal(t, service.Money{Amount: 1999, Currency: "USD"}, product.Price)
+	})
+
+	t.Run("sets the creation and update timestamps", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Timestamped Product", 1))
+
+		assert.WithinDuration(t, time.Now(), product.CreatedAt, timestampTolerance)
+		assert.WithinDuration(t, product.CreatedAt, product.LastUpdated, timestampTolerance)
+	})
+
+	t.Run("assigns distinct increasing IDs", func(t *testing.T) {
+		first := CreateProduct(t, store, newPayload("First Product", 1))
+		second := CreateProduct(t, store, newPayload("Second Product", 1))
+
+		assert.Greater(t, second.ID, first.ID)
+	})
+}
+
+func testRetrieve(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	t.Run("returns the stored product", func(t *testing.T) {
+		created := CreateProduct(t, store, newPayload("Stored Product", 3))
+
+		retrieved, err := store.Retrieve(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, retrieved.ID)
+		assert.Equal(t, created.Name, retrieved.Name)
+		assert.Equal(t, created.Quantity, retrieved.Quantity)
+	})
+
+	t.Run("fails for an unknown ID", func(t *testing.T) {
+		retrieved, err := store.Retrieve(ctx, service.ProductID(999999))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.Nil(t, retrieved)
+	})
+
+	t.Run("returns products detached from the store", func(t *testing.T) {
+		created := CreateProduct(t, store, newPayload("Detached Product", 3))
+
+		retrieved, err := store.Retrieve(ctx, created.ID)
+		require.NoError(t, err)
+		retrieved.Name = "Modified Without Update"
+
+		retrieved, err = store.Retrieve(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Detached Product", retrieved.Name)
+	})
+}
+
+func testSKU(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	product := CreateProduct(t, store, newPayload("Catalogued Product", 1))
+	other := CreateProduct(t, store, newPayload("Other Product", 1))
+
+	t.Run("retrieves a product by its SKU", func(t *testing.T) {
+		retrieved, err := store.RetrieveBySKU(ctx, product.SKU)
+		require.NoError(t, err)
+		assert.Equal(t, product.ID, retrieved.ID)
+		assert.Equal(t, product.SKU, retrieved.SKU)
+		assert.Equal(t, "Catalogued Product", retrieved.Name)
+	})
+
+	t.Run("fails for an unknown SKU", func(t *testing.T) {
+		_, err := store.RetrieveBySKU(ctx, "UNKNOWN-SKU")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("rejects duplicate SKUs on creation", func(t *testing.T) {
+		payload := newPayload("Duplicate Product", 1)
+		payload.SKU = product.SKU
+		duplicate := service.NewProduct(payload)
+		assert.ErrorIs(t, store.Create(ctx, &duplicate), storage.ErrConflict)
+
+		products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, products, 2)
+	})
+
+	t.Run("rejects duplicate SKUs on update", func(t *testing.T) {
+		updated := *other
+		updated.SKU = product.SKU
+		updatedProduct := &updated
+		assert.ErrorIs(t, store.Update(ctx, &updatedProduct), storage.ErrConflict)
+
+		retrieved, err := store.Retrieve(ctx, other.ID)
+		require.NoError(t, err)
+		assert.Equal(t, other.SKU, retrieved.SKU)
+	})
+
+	t.Run("changes the SKU on update", func(t *testing.T) {
+		other.SKU = "RENAMED-SKU"
+		require.NoError(t, store.Update(ctx, &other))
+		assert.Equal(t, "RENAMED-SKU", other.SKU)
+
+		retrieved, err := store.RetrieveBySKU(ctx, "RENAMED-SKU")
+		require.NoError(t, err)
+		assert.Equal(t, other.ID, retrieved.ID)
+	})
+}
+
+func testRetrieveAll(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	t.Run("returns nothing for an empty store", func(t *testing.T) {
+		products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Empty(t, products)
+
+		count, err := store.Count(ctx, &service.ProductQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	var ids []service.ProductID
+	for i := 1; i <= 5; i++ {
+		ids = append(ids, CreateProduct(t, store, newPayload(fmt.Sprintf("Product %d", i), i)).ID)
+	}
+
+	t.Run("paginates in ID order", func(t *testing.T) {
+		for page, expected := range [][]service.ProductID{ids[0:2], ids[2:4], ids[4:5], {}} {
+			products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: page + 1, Limit: 2})
+			require.NoError(t, err)
+			assert.Equal(t, expected, productIDs(products), "page %d", page+1)
+		}
+	})
+
+	t.Run("returns the same order on every call", func(t *testing.T) {
+		first, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 5})
+		require.NoError(t, err)
+		second, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 5})
+		require.NoError(t, err)
+
+		assert.Equal(t, ids, productIDs(first))
+		assert.Equal(t, productIDs(first), productIDs(second))
+	})
+
+	t.Run("continues after a cursor", func(t *testing.T) {
+		var listed []service.ProductID
+		query := &service.ProductQuery{Limit: 2}
+		for range 3 {
+			products, err := store.RetrieveAll(ctx, query)
+			require.NoError(t, err)
+			require.NotEmpty(t, products)
+
+			listed = append(listed, productIDs(products)...)
+			query.After = service.CursorAfter(products[len(products)-1], query.OrderBy())
+		}
+
+		assert.Equal(t, ids, listed)
+
+		products, err := store.RetrieveAll(ctx, query)
+		require.NoError(t, err)
+		assert.Empty(t, products)
+	})
+
+	t.Run("ignores the page number after a cursor", func(t *testing.T) {
+		products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 3, Limit: 2, After: &service.Cursor{Values: []any{ids[0]}}})
+		require.NoError(t, err)
+		assert.Equal(t, ids[1:3], productIDs(products))
+	})
+
+	t.Run("counts every product regardless of the page", func(t *testing.T) {
+		count, err := store.Count(ctx, &service.ProductQuery{Page: 2, Limit: 2, After: &service.Cursor{Values: []any{ids[3]}}})
+		require.NoError(t, err)
+		assert.Equal(t, len(ids), count)
+	})
+
+	t.Run("applies defaults to invalid pagination", func(t *testing.T) {
+		products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 0, Limit: 0})
+		require.NoError(t, err)
+		assert.Equal(t, ids, productIDs(products))
+	})
+}
+
+func testFilterAndSort(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+
+	products := map[string]service.ProductID{}
+	for _, product := range []struct {
+		name     string
+		price    int64
+		quantity int
+	}{
+		{"apple juice", 350, 0},
+		{"banana bread", 725, 4},
+		{"cherry jam", 725, 2},
+		{"Apple Pie", 1200, 1},
+		{"date syrup", 100, 5},
+		{"cocoa_nibs", 900, 3},
+	} {
+		payload := newPayload(product.name, product.quantity)
+		payload.Price = amount(product.price)
+		products[product.name] = CreateProduct(t, store, payload).ID
+	}
+
+	idsOf := func(names ...string) []service.ProductID {
+		ids := []service.ProductID{}
+		for _, name := range names {
+			ids = append(ids, products[name])
+		}
+		return ids
+	}
+	price := func(minorUnits int64) *service.Money { price := amount(minorUnits); return &price }
+	inStock := true
+
+	t.Run("filters products", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			filter   service.ProductFilter
+			expected []service.ProductID
+		}{
+			{"by name ignoring case", service.ProductFilter{NameContains: "APPLE"}, idsOf("apple juice", "Apple Pie")},
+			{"by name without wildcards", service.ProductFilter{NameContains: "a_n"}, idsOf("cocoa_nibs")},
+			{"by price range", service.ProductFilter{MinPrice: price(500), MaxPrice: price(900)}, idsOf("banana bread", "cherry jam", "cocoa_nibs")},
+			{"by stock", service.ProductFilter{InStock: &inStock, MaxPrice: price(800)}, idsOf("banana bread", "cherry jam", "date syrup")},
+		}
+
+		for _, testCase := range testCases {
+			t.Run(testCase.name, func(t *testing.T) {
+				query := &service.ProductQuery{Filter: testCase.filter}
+
+				listed, err := store.RetrieveAll(ctx, query)
+				require.NoError(t, err)
+				assert.Equal(t, testCase.expected, productIDs(listed))
+
+				count, err := store.Count(ctx, query)
+				require.NoError(t, err)
+				assert.Equal(t, len(testCase.expected), count)
+			})
+		}
+	})
+
+	t.Run("sorts products with ties broken by the next key", func(t *testing.T) {
+		sort, err := service.ParseSort("-price,name")
+		require.NoError(t, err)
+
+		listed, err := store.RetrieveAll(ctx, &service.ProductQuery{Sort: sort})
+		require.NoError(t, err)
+		assert.Equal(t, idsOf("Apple Pie", "cocoa_nibs", "banana bread", "cherry jam", "apple juice", "date syrup"), productIDs(listed))
+	})
+
+	t.Run("pages through sorted products by number", func(t *testing.T) {
+		sort, err := service.ParseSort("-quantity")
+		require.NoError(t, err)
+
+		listed, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 2, Limit: 2, Sort: sort})
+		require.NoError(t, err)
+		assert.Equal(t, idsOf("cocoa_nibs", "cherry jam"), productIDs(listed))
+	})
+
+	t.Run("pages through sorted and filtered products by cursor", func(t *testing.T) {
+		sort, err := service.ParseSort("-price,name")
+		require.NoError(t, err)
+
+		query := &service.ProductQuery{Limit: 3, Sort: sort, Filter: service.ProductFilter{InStock: &inStock}}
+		var pages [][]service.ProductID
+		for range 3 {
+			listed, err := store.RetrieveAll(ctx, query)
+			require.NoError(t, err)
+			if len(listed) == 0 {
+				break
+			}
+
+			pages = append(pages, productIDs(listed))
+			query.After = service.CursorAfter(listed[len(listed)-1], query.OrderBy())
+		}
+
+		assert.Equal(t, [][]service.ProductID{
+			idsOf("Apple Pie", "cocoa_nibs", "banana bread"),
+			idsOf("cherry jam", "date syrup"),
+		}, pages)
+	})
+}
+
+func testSearch(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+
+	// Every term searched for appears in a minority of the products, as MySQL disregards terms found in most rows.
+	products := map[string]*service.Product{}
+	for _, product := range []struct{ name, description string }{
+		{"Espresso Machine", "Brews espresso and cappuccino at home"},
+		{"Coffee Grinder", "Grinds roasted beans for espresso"},
+		{"Tea Kettle", "Boils water quickly"},
+		{"Water Filter", "Removes chlorine from tap water"},
+		{"Garden Hose", "Flexible hose for watering plants"},
+	} {
+		payload := newPayload(product.name, 1)
+		payload.Description = product.description
+		products[product.name] = CreateProduct(t, store, payload)
+	}
+
+	search := func(t *testing.T, query *service.SearchQuery) []service.ProductID {
+		t.Helper()
+
+		hits, err := store.Search(ctx, query)
+		require.NoError(t, err)
+
+		ids := []service.ProductID{}
+		for i, hit := range hits {
+			assert.Positive(t, hit.Score)
+			if i > 0 {
+				assert.LessOrEqual(t, hit.Score, hits[i-1].Score)
+			}
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	t.Run("ranks name matches above description matches", func(t *testing.T) {
+		expected := []service.ProductID{products["Espresso Machine"].ID, products["Coffee Grinder"].ID}
+
+		assert.Equal(t, expected, search(t, &service.SearchQuery{Text: "espresso"}))
+		assert.Equal(t, expected, search(t, &service.SearchQuery{Text: "ESPRESSO!"}))
+	})
+
+	t.Run("matches any of the terms", func(t *testing.T) {
+		assert.ElementsMatch(t,
+			[]service.ProductID{products["Espresso Machine"].ID, products["Coffee Grinder"].ID},
+			search(t, &service.SearchQuery{Text: "cappuccino grinder"}))
+	})
+
+	t.Run("returns the product details", func(t *testing.T) {
+		hits, err := store.Search(ctx, &service.SearchQuery{Text: "kettle"})
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, products["Tea Kettle"].Name, hits[0].Name)
+		assert.Equal(t, products["Tea Kettle"].Description, hits[0].Description)
+		assert.Equal(t, products["Tea Kettle"].Version, hits[0].Version)
+	})
+
+	t.Run("returns nothing without a match", func(t *testing.T) {
+		for _, text := range []string{"bicycle", "", "?!"} {
+			assert.Empty(t, search(t, &service.SearchQuery{Text: text}), text)
+		}
+	})
+
+	t.Run("paginates the results", func(t *testing.T) {
+		assert.Equal(t,
+			[]service.ProductID{products["Coffee Grinder"].ID},
+			search(t, &service.SearchQuery{Text: "espresso", Page: 2, Limit: 1}))
+	})
+
+	t.Run("follows updates and deletions", func(t *testing.T) {
+		product := products["Tea Kettle"]
+		service.UpdateProduct(product, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: -1, Discount: -1, Name: "Tea Boiler"})
+		require.NoError(t, store.Update(ctx, &product))
+		require.NoError(t, store.Delete(ctx, products["Garden Hose"]))
+
+		assert.Empty(t, search(t, &service.SearchQuery{Text: "kettle hose"}))
+		assert.Equal(t, []service.ProductID{product.ID}, search(t, &service.SearchQuery{Text: "boiler"}))
+	})
+}
+
+func testUpdate(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	t.Run("persists the updated details", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Original Product", 10))
+
+		service.UpdateProduct(product, &service.ProductUpdatePayload{
+			Price:       amount(2450),
+			ID:          product.ID,
+			Quantity:    -1,
+			Discount:    10,
+			Name:        "Updated Product",
+			Description: "Updated description",
+		})
+		require.NoError(t, store.Update(ctx, &product))
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated Product", retrieved.Name)
+		assert.Equal(t, "Updated description", retrieved.Description)
+		assert.Equal(t, amount(2450), retrieved.Price)
+		assert.Equal(t, float32(10), retrieved.Discount)
+		assert.Equal(t, 10, retrieved.Quantity)
+	})
+
+	t.Run("applies quantity changes as deltas", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Stocked Product", 10))
+
+		// Both unconditional updates are computed from the same stale read; neither may overwrite the other.
+		first, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		second, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		first.Version, second.Version = 0, 0
+
+		service.UpdateProduct(first, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 15, Discount: -1})
+		require.NoError(t, store.Update(ctx, &first))
+		assert.Equal(t, 15, first.Quantity)
+
+		service.UpdateProduct(second, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 7, Discount: -1})
+		require.NoError(t, store.Update(ctx, &second))
+		assert.Equal(t, 12, second.Quantity)
+	})
+
+	t.Run("rejects changes driving stock negative", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Scarce Product", 5))
+
+		stale, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		stale.Version = 0
+
+		service.UpdateProduct(product, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 2, Discount: -1})
+		require.NoError(t, store.Update(ctx, &product))
+
+		// Removing 5 units from the remaining 2 must leave the stock untouched.
+		service.UpdateProduct(stale, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 0, Discount: -1})
+		err = store.Update(ctx, &stale)
+		assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+
+		var stockErr *storage.InsufficientStockError
+		if assert.ErrorAs(t, err, &stockErr) {
+			assert.Equal(t, product.ID, stockErr.ProductID)
+			assert.Equal(t, 5, stockErr.Requested)
+			assert.Equal(t, 2, stockErr.Available)
+		}
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, retrieved.Quantity)
+	})
+
+	t.Run("increments the version", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Versioned Product", 1))
+		assert.Equal(t, int64(1), product.Version)
+
+		service.UpdateProduct(product, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: -1, Discount: -1, Name: "Renamed"})
+		require.NoError(t, store.Update(ctx, &product))
+		assert.Equal(t, int64(2), product.Version)
+
+		adjusted, err := store.AdjustStock(ctx, product.ID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), adjusted.Version)
+	})
+
+	t.Run("rejects stale versions", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Contended Product", 1))
+
+		first, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		second, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+
+		service.UpdateProduct(first, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: -1, Discount: -1, Name: "First Writer"})
+		require.NoError(t, store.Update(ctx, &first))
+
+		service.UpdateProduct(second, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: -1, Discount: -1, Name: "Second Writer"})
+		assert.ErrorIs(t, store.Update(ctx, &second), storage.ErrVersionMismatch)
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "First Writer", retrieved.Name)
+		assert.Equal(t, int64(2), retrieved.Version)
+	})
+
+	t.Run("refreshes the update timestamp", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Timestamped Product", 1))
+		createdAt := product.CreatedAt
+
+		service.UpdateProduct(product, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: -1, Discount: -1, Name: "Renamed"})
+		require.NoError(t, store.Update(ctx, &product))
+
+		assert.WithinDuration(t, createdAt, product.CreatedAt, timestampTolerance)
+		assert.False(t, product.LastUpdated.Before(product.CreatedAt.Truncate(time.Second)))
+		assert.WithinDuration(t, time.Now(), product.LastUpdated, timestampTolerance)
+	})
+
+	t.Run("never reports a skipped update as successful under concurrent writes", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Raced Product", 0))
+
+		// Removals of 5 units computed from a stale read must fail even if a refill lands before the rejection is explained.
+		var removed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := store.AdjustStock(ctx, product.ID, 5)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				stale := *product
+				stale.Version, stale.Quantity = 0, 5
+				updated := &stale
+				service.UpdateProduct(updated, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 0, Discount: -1})
+				if err := store.Update(ctx, &updated); err == nil {
+					removed.Add(1)
+				} else {
+					assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+				}
+			}()
+		}
+		wg.Wait()
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 100-5*int(removed.Load()), retrieved.Quantity)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		product := service.NewProduct(newPayload("Unknown Product", 1))
+		product.ID = service.ProductID(999999)
+
+		assert.ErrorIs(t, store.Update(ctx, &product), storage.ErrNotFound)
+	})
+}
+
+func testAdjustStock(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	t.Run("adds and removes units", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Adjusted Product", 10))
+
+		adjusted, err := store.AdjustStock(ctx, product.ID, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 15, adjusted.Quantity)
+
+		adjusted, err = store.AdjustStock(ctx, product.ID, -15)
+		require.NoError(t, err)
+		assert.Equal(t, 0, adjusted.Quantity)
+		assert.Equal(t, product.Name, adjusted.Name)
+	})
+
+	t.Run("rejects removals exceeding the stock", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Scarce Product", 3))
+
+		_, err := store.AdjustStock(ctx, product.ID, -4)
+		assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+
+		var stockErr *storage.InsufficientStockError
+		if assert.ErrorAs(t, err, &stockErr) {
+			assert.Equal(t, 4, stockErr.Requested)
+			assert.Equal(t, 3, stockErr.Available)
+		}
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, retrieved.Quantity)
+	})
+
+	t.Run("never oversells under concurrent removals", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Popular Product", 50))
+
+		var succeeded atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.AdjustStock(ctx, product.ID, -5); err == nil {
+					succeeded.Add(1)
+				} else {
+					assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+				}
+			}()
+		}
+		wg.Wait()
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), succeeded.Load())
+		assert.Equal(t, 0, retrieved.Quantity)
+	})
+
+	t.Run("never reports a skipped removal as successful under concurrent refills", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Restocked Product", 0))
+
+		// A removal rejected for lack of stock must fail even if a refill lands before the rejection is explained.
+		var removed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := store.AdjustStock(ctx, product.ID, 5)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := store.AdjustStock(ctx, product.ID, -5); err == nil {
+					removed.Add(1)
+				} else {
+					assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+				}
+			}()
+		}
+		wg.Wait()
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 100-5*int(removed.Load()), retrieved.Quantity)
+		assert.Equal(t, int64(1+20+removed.Load()), retrieved.Version)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		_, err := store.AdjustStock(ctx, service.ProductID(999999), 1)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func testDelete(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	t.Run("removes the product", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Deleted Product", 1))
+
+		require.NoError(t, store.Delete(ctx, product))
+
+		_, err := store.Retrieve(ctx, product.ID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("rejects stale versions", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Contended Product", 1))
+
+		_, err := store.AdjustStock(ctx, product.ID, 1)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, store.Delete(ctx, product), storage.ErrVersionMismatch)
+
+		_, err = store.Retrieve(ctx, product.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("never reports a skipped update as successful under concurrent writes", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Raced Product", 0))
+
+		// Removals of 5 units computed from a stale read must fail even if a refill lands before the rejection is explained.
+		var removed atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := store.AdjustStock(ctx, product.ID, 5)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				stale := *product
+				stale.Version, stale.Quantity = 0, 5
+				updated := &stale
+				service.UpdateProduct(updated, &service.ProductUpdatePayload{Price: service.Money{Amount: -1}, ID: product.ID, Quantity: 0, Discount: -1})
+				if err := store.Update(ctx, &updated); err == nil {
+					removed.Add(1)
+				} else {
+					assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+				}
+			}()
+		}
+		wg.Wait()
+
+		retrieved, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 100-5*int(removed.Load()), retrieved.Quantity)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		product := service.NewProduct(newPayload("Unknown Product", 1))
+		product.ID = service.ProductID(999999)
+
+		assert.ErrorIs(t, store.Delete(ctx, product), storage.ErrNotFound)
+	})
+}
+
+func testExchangeRates(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+
+	saveRate := func(t *testing.T, base, quote service.Currency, rate service.Rate) *service.ExchangeRate {
+		t.Helper()
+		exchangeRate := &service.ExchangeRate{Base: base, Quote: quote, Rate: rate, UpdatedAt: updatedAt}
+		require.NoError(t, store.SaveExchangeRate(ctx, exchangeRate))
+		return exchangeRate
+	}
+
+	t.Run("saves and retrieves a rate", func(t *testing.T) {
+		saveRate(t, "EUR", "USD", 1085000)
+
+		exchangeRate, err := store.RetrieveExchangeRate(ctx, "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, service.Currency("EUR"), exchangeRate.Base)
+		assert.Equal(t, service.Currency("USD"), exchangeRate.Quote)
+		assert.Equal(t, service.Rate(1085000), exchangeRate.Rate)
+		assert.WithinDuration(t, updatedAt, exchangeRate.UpdatedAt, timestampTolerance)
+	})
+
+	t.Run("replaces the rate of the same pair", func(t *testing.T) {
+		saveRate(t, "EUR", "GBP", 850000)
+		saveRate(t, "EUR", "GBP", 860000)
+
+		exchangeRate, err := store.RetrieveExchangeRate(ctx, "EUR", "GBP")
+		require.NoError(t, err)
+		assert.Equal(t, service.Rate(860000), exchangeRate.Rate)
+	})
+
+	t.Run("retrieves the rate stored in the opposite direction", func(t *testing.T) {
+		saveRate(t, "GBP", "USD", 1270000)
+
+		exchangeRate, err := store.RetrieveExchangeRate(ctx, "USD", "GBP")
+		require.NoError(t, err)
+		assert.Equal(t, service.Currency("GBP"), exchangeRate.Base)
+		assert.Equal(t, service.Currency("USD"), exchangeRate.Quote)
+	})
+
+	t.Run("lists the rates ordered by base and quote currency", func(t *testing.T) {
+		exchangeRates, err := store.RetrieveExchangeRates(ctx)
+		require.NoError(t, err)
+
+		var pairs []string
+		for _, exchangeRate := range exchangeRates {
+			pairs = append(pairs, string(exchangeRate.Base+"/"+exchangeRate.Quote))
+		}
+		assert.Equal(t, []string{"EUR/GBP", "EUR/USD", "GBP/USD"}, pairs)
+	})
+
+	t.Run("deletes a rate", func(t *testing.T) {
+		require.NoError(t, store.DeleteExchangeRate(ctx, "GBP", "USD"))
+
+		_, err := store.RetrieveExchangeRate(ctx, "GBP", "USD")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("fails to delete an unknown rate", func(t *testing.T) {
+		assert.ErrorIs(t, store.DeleteExchangeRate(ctx, "USD", "EUR"), storage.ErrNotFound)
+	})
+}
+
+func testPriceHistory(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+
+	t.Run("records price changes, the most recent first", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Repriced Product", 1))
+
+		for _, price := range []int64{2499, 2999} {
+			product.Price = amount(price)
+			product.LastUpdated = time.Now().UTC()
+			require.NoError(t, store.Update(ctx, &product))
+		}
+
+		changes, err := store.RetrievePriceHistory(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, amount(2499), changes[0].PreviousPrice)
+		assert.Equal(t, amount(2999), changes[0].Price)
+		assert.Equal(t, amount(1999), changes[1].PreviousPrice)
+		assert.Equal(t, amount(2499), changes[1].Price)
+		assert.Equal(t, product.ID, changes[1].ProductID)
+	})
+
+	t.Run("records currency changes", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Dollar Product", 1))
+
+		product.Price.Currency = "USD"
+		require.NoError(t, store.Update(ctx, &product))
+
+		changes, err := store.RetrievePriceHistory(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		assert.Equal(t, service.Money{Amount: 1999, Currency: "USD"}, changes[0].Price)
+	})
+
+	t.Run("ignores updates keeping the price", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Renamed Product", 1))
+
+		product.Name = "Renamed Product v2"
+		require.NoError(t, store.Update(ctx, &product))
+
+		changes, err := store.RetrievePriceHistory(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("records nothing for rejected updates", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Rejected Product", 1))
+
+		product.Price = amount(999)
+		product.Version = product.Version + 1
+		assert.ErrorIs(t, store.Update(ctx, &product), storage.ErrVersionMismatch)
+
+		changes, err := store.RetrievePriceHistory(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		_, err := store.RetrievePriceHistory(ctx, service.ProductID(999999))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func testScheduledPrices(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	schedulePrice := func(t *testing.T, product *service.Product, price int64, effectiveAt time.Time) *service.ScheduledPrice {
+		t.Helper()
+		scheduledPrice := &service.ScheduledPrice{ProductID: product.ID, Price: amount(price), EffectiveAt: effectiveAt, CreatedAt: now}
+		require.NoError(t, store.SchedulePrice(ctx, scheduledPrice))
+		return scheduledPrice
+	}
+
+	t.Run("lists the pending changes, the earliest first", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Scheduled Product", 1))
+		later := schedulePrice(t, product, 2999, now.Add(2*time.Hour))
+		sooner := schedulePrice(t, product, 2499, now.Add(time.Hour))
+
+		scheduledPrices, err := store.RetrieveScheduledPrices(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, scheduledPrices, 2)
+		assert.Equal(t, sooner.ID, scheduledPrices[0].ID)
+		assert.Equal(t, later.ID, scheduledPrices[1].ID)
+		assert.Equal(t, amount(2499), scheduledPrices[0].Price)
+		assert.WithinDuration(t, now.Add(time.Hour), scheduledPrices[0].EffectiveAt, timestampTolerance)
+	})
+
+	t.Run("applies the due changes in order", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Due Product", 1))
+		schedulePrice(t, product, 2999, now.Add(-time.Hour))
+		schedulePrice(t, product, 2499, now.Add(-2*time.Hour))
+		pending := schedulePrice(t, product, 3999, now.Add(time.Hour))
+
+		applied, err := store.ApplyDuePrices(ctx, now)
+		require.NoError(t, err)
+		assert.Equal(t, 2, applied)
+
+		updated, err := store.Retrieve(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, amount(2999), updated.Price)
+		assert.Equal(t, product.Version+2, updated.Version)
+
+		changes, err := store.RetrievePriceHistory(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		assert.Equal(t, amount(2499), changes[0].PreviousPrice)
+		assert.Equal(t, amount(2999), changes[0].Price)
+
+		scheduledPrices, err := store.RetrieveScheduledPrices(ctx, product.ID)
+		require.NoError(t, err)
+		require.Len(t, scheduledPrices, 1)
+		assert.Equal(t, pending.ID, scheduledPrices[0].ID)
+	})
+
+	t.Run("applies nothing once the due changes are applied", func(t *testing.T) {
+		applied, err := store.ApplyDuePrices(ctx, now)
+		require.NoError(t, err)
+		assert.Zero(t, applied)
+	})
+
+	t.Run("cancels a pending change", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Canceled Product", 1))
+		scheduledPrice := schedulePrice(t, product, 2999, now.Add(time.Hour))
+
+		require.NoError(t, store.CancelScheduledPrice(ctx, product.ID, scheduledPrice.ID))
+		assert.ErrorIs(t, store.CancelScheduledPrice(ctx, product.ID, scheduledPrice.ID), storage.ErrNotFound)
+
+		scheduledPrices, err := store.RetrieveScheduledPrices(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, scheduledPrices)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		scheduledPrice := &service.ScheduledPrice{ProductID: service.ProductID(999999), Price: amount(999), EffectiveAt: now, CreatedAt: now}
+		assert.ErrorIs(t, store.SchedulePrice(ctx, scheduledPrice), storage.ErrNotFound)
+	})
+
+	t.Run("discards the changes of deleted products", func(t *testing.T) {
+		product := CreateProduct(t, store, newPayload("Discontinued Product", 1))
+		schedulePrice(t, product, 2999, now.Add(time.Hour))
+
+		require.NoError(t, store.Delete(ctx, product))
+
+		scheduledPrices, err := store.RetrieveScheduledPrices(ctx, product.ID)
+		require.NoError(t, err)
+		assert.Empty(t, scheduledPrices)
+	})
+}
+
+func testTags(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	tagged := func(name string, tags ...string) *service.Product {
+		payload := newPayload(name, 1)
+		payload.Tags = tags
+		return CreateProduct(t, store, payload)
+	}
+
+	shirt := tagged("Shirt", "Summer", "sale")
+	shorts := tagged("Shorts", "summer")
+	coat := tagged("Coat", "winter", "sale")
+	plain := tagged("Plain")
+
+	t.Run("persists the normalized tags", func(t *testing.T) {
+		assert.Equal(t, []string{"sale", "summer"}, shirt.Tags)
+		assert.Equal(t, []string{}, plain.Tags)
+
+		retrieved, err := store.Retrieve(ctx, shirt.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sale", "summer"}, retrieved.Tags)
+	})
+
+	t.Run("filters products carrying all or any of the tags", func(t *testing.T) {
+		for _, test := range []struct {
+			filter service.ProductFilter
+			ids    []service.ProductID
+		}{
+			{service.ProductFilter{Tags: []string{"sale"}}, []service.ProductID{shirt.ID, coat.ID}},
+			{service.ProductFilter{Tags: []string{"sale", "summer"}}, []service.ProductID{shirt.ID}},
+			{service.ProductFilter{Tags: []string{"sale", "summer"}, MatchAnyTag: true}, []service.ProductID{shirt.ID, shorts.ID, coat.ID}},
+			{service.ProductFilter{Tags: []string{"spring"}}, []service.ProductID{}},
+		} {
+			query := &service.ProductQuery{Page: 1, Limit: 10, Filter: test.filter}
+
+			products, err := store.RetrieveAll(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, test.ids, productIDs(products), test.filter)
+
+			count, err := store.Count(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, len(test.ids), count, test.filter)
+		}
+	})
+
+	t.Run("returns the tags of listed and searched products", func(t *testing.T) {
+		products, err := store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, products, 4)
+		assert.Equal(t, []string{"sale", "winter"}, products[2].Tags)
+
+		hits, err := store.Search(ctx, &service.SearchQuery{Text: "coat"})
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, []string{"sale", "winter"}, hits[0].Product.Tags)
+	})
+
+	t.Run("counts the products of every tag, the most used first", func(t *testing.T) {
+		tagCounts, err := store.RetrieveTagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*service.TagCount{{Tag: "sale", Count: 2}, {Tag: "summer", Count: 2}, {Tag: "winter", Count: 1}}, tagCounts)
+	})
+
+	t.Run("replaces the tags on update", func(t *testing.T) {
+		shorts.Tags = []string{"Clearance"}
+		require.NoError(t, store.Update(ctx, &shorts))
+		assert.Equal(t, []string{"clearance"}, shorts.Tags)
+
+		retrieved, err := store.Retrieve(ctx, shorts.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"clearance"}, retrieved.Tags)
+	})
+
+	t.Run("keeps the tags on rejected updates", func(t *testing.T) {
+		stale := *coat
+		stale.Tags = []string{"spring"}
+		stale.Version = coat.Version + 1
+		staleProduct := &stale
+		assert.ErrorIs(t, store.Update(ctx, &staleProduct), storage.ErrVersionMismatch)
+
+		retrieved, err := store.Retrieve(ctx, coat.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sale", "winter"}, retrieved.Tags)
+	})
+
+	t.Run("drops the tags of deleted products", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, coat))
+
+		tagCounts, err := store.RetrieveTagCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*service.TagCount{{Tag: "clearance", Count: 1}, {Tag: "sale", Count: 1}, {Tag: "summer", Count: 1}}, tagCounts)
+	})
+}
+
+// CreateCategory stores a new category with the given name and parent, failing the test if the creation fails.
+//
+// Parameters:
+// - t: The running test.
+// - store: The store the category is created in.
+// - name: The name of the category.
+// - parent: The parent category, or nil for a root category.
+//
+// Returns:
+// - A pointer to the created Category, as returned by the store.
+func CreateCategory(t *testing.T, store storage.ProductStore, name string, parent *service.Category) *service.Category {
+	t.Helper()
+
+	payload := &service.CategoryPayload{Name: name}
+	if parent != nil {
+		payload.ParentID = &parent.ID
+	}
+
+	category := service.NewCategory(payload)
+	require.NoError(t, store.CreateCategory(context.Background(), category))
+
+	return category
+}
+
+func testCategories(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	electronics := CreateCategory(t, store, "Electronics", nil)
+	phones := CreateCategory(t, store, "Phones", electronics)
+	smartphones := CreateCategory(t, store, "Smartphones", phones)
+	books := CreateCategory(t, store, "Books", nil)
+
+	t.Run("assigns an ID and persists every field", func(t *testing.T) {
+		assert.NotZero(t, phones.ID)
+
+		retrieved, err := store.RetrieveCategory(ctx, phones.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Phones", retrieved.Name)
+		require.NotNil(t, retrieved.ParentID)
+		assert.Equal(t, electronics.ID, *retrieved.ParentID)
+		assert.WithinDuration(t, phones.CreatedAt, retrieved.CreatedAt, timestampTolerance)
+	})
+
+	t.Run("lists the categories ordered by name", func(t *testing.T) {
+		categories, err := store.RetrieveCategories(ctx)
+		require.NoError(t, err)
+
+		var names []string
+		for _, category := range categories {
+			names = append(names, category.Name)
+		}
+		assert.Equal(t, []string{"Books", "Electronics", "Phones", "Smartphones"}, names)
+	})
+
+	t.Run("rejects unknown parents", func(t *testing.T) {
+		unknown := service.CategoryID(999999)
+		category := service.NewCategory(&service.CategoryPayload{Name: "Orphan", ParentID: &unknown})
+		assert.ErrorIs(t, store.CreateCategory(ctx, category), storage.ErrConflict)
+	})
+
+	t.Run("moves a category under another parent", func(t *testing.T) {
+		phones.Name = "Mobile Phones"
+		phones.ParentID = &books.ID
+		require.NoError(t, store.UpdateCategory(ctx, phones))
+
+		retrieved, err := store.RetrieveCategory(ctx, phones.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Mobile Phones", retrieved.Name)
+		require.NotNil(t, retrieved.ParentID)
+		assert.Equal(t, books.ID, *retrieved.ParentID)
+
+		phones.ParentID = &electronics.ID
+		require.NoError(t, store.UpdateCategory(ctx, phones))
+	})
+
+	t.Run("rejects moves creating cycles", func(t *testing.T) {
+		for _, parent := range []*service.Category{electronics, smartphones} {
+			moved := *electronics
+			moved.ParentID = &parent.ID
+			assert.ErrorIs(t, store.UpdateCategory(ctx, &moved), storage.ErrConflict)
+		}
+
+		retrieved, err := store.RetrieveCategory(ctx, electronics.ID)
+		require.NoError(t, err)
+		assert.Nil(t, retrieved.ParentID)
+	})
+
+	t.Run("rejects deleting categories with subcategories", func(t *testing.T) {
+		assert.ErrorIs(t, store.DeleteCategory(ctx, electronics.ID), storage.ErrConflict)
+	})
+
+	t.Run("deletes a category", func(t *testing.T) {
+		require.NoError(t, store.DeleteCategory(ctx, smartphones.ID))
+
+		_, err := store.RetrieveCategory(ctx, smartphones.ID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("fails for an unknown category", func(t *testing.T) {
+		_, err := store.RetrieveCategory(ctx, service.CategoryID(999999))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		unknown := service.NewCategory(&service.CategoryPayload{Name: "Unknown"})
+		unknown.ID = service.CategoryID(999999)
+		assert.ErrorIs(t, store.UpdateCategory(ctx, unknown), storage.ErrNotFound)
+		assert.ErrorIs(t, store.DeleteCategory(ctx, unknown.ID), storage.ErrNotFound)
+	})
+}
+
+func testProductCategories(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	electronics := CreateCategory(t, store, "Electronics", nil)
+	phones := CreateCategory(t, store, "Phones", electronics)
+	books := CreateCategory(t, store, "Books", nil)
+
+	phone := CreateProduct(t, store, newPayload("Phone", 1))
+	novel := CreateProduct(t, store, newPayload("Novel", 1))
+	CreateProduct(t, store, newPayload("Uncategorized", 1))
+
+	require.NoError(t, store.SetProductCategories(ctx, phone.ID, []service.CategoryID{phones.ID, electronics.ID, phones.ID}))
+	require.NoError(t, store.SetProductCategories(ctx, novel.ID, []service.CategoryID{books.ID}))
+
+	t.Run("lists the linked categories ordered by name", func(t *testing.T) {
+		categories, err := store.RetrieveProductCategories(ctx, phone.ID)
+		require.NoError(t, err)
+		require.Len(t, categories, 2)
+		assert.Equal(t, electronics.ID, categories[0].ID)
+		assert.Equal(t, phones.ID, categories[1].ID)
+	})
+
+	t.Run("filters products by category", func(t *testing.T) {
+		query := &service.ProductQuery{Page: 1, Limit: 10, Filter: service.ProductFilter{CategoryIDs: []service.CategoryID{phones.ID, books.ID}}}
+
+		products, err := store.RetrieveAll(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, []service.ProductID{phone.ID, novel.ID}, productIDs(products))
+
+		count, err := store.Count(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("replaces the linked categories", func(t *testing.T) {
+		require.NoError(t, store.SetProductCategories(ctx, novel.ID, []service.CategoryID{}))
+
+		categories, err := store.RetrieveProductCategories(ctx, novel.ID)
+		require.NoError(t, err)
+		assert.Empty(t, categories)
+	})
+
+	t.Run("rejects unknown categories", func(t *testing.T) {
+		err := store.SetProductCategories(ctx, phone.ID, []service.CategoryID{books.ID, service.CategoryID(999999)})
+		assert.ErrorIs(t, err, storage.ErrConflict)
+
+		categories, err := store.RetrieveProductCategories(ctx, phone.ID)
+		require.NoError(t, err)
+		assert.Len(t, categories, 2)
+	})
+
+	t.Run("unlinks deleted categories", func(t *testing.T) {
+		require.NoError(t, store.DeleteCategory(ctx, phones.ID))
+
+		categories, err := store.RetrieveProductCategories(ctx, phone.ID)
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, electronics.ID, categories[0].ID)
+	})
+
+	t.Run("fails for an unknown product", func(t *testing.T) {
+		_, err := store.RetrieveProductCategories(ctx, service.ProductID(999999))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		err = store.SetProductCategories(ctx, service.ProductID(999999), []service.CategoryID{books.ID})
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func testVariants(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+	shirt := CreateProduct(t, store, newPayload("Shirt", 0))
+	variant := func(sku, size string, quantity int, price *service.Money) *service.Variant {
+		payload := &service.VariantCreationPayload{SKU: sku, Size: size, Color: "blue", Price: price, Quantity: quantity}
+		return service.NewVariant(shirt, payload)
+	}
+
+	override := amount(2499)
+	small := variant("SHIRT-S-BLUE", "S", 10, nil)
+	large := variant("SHIRT-L-BLUE", "L", 5, &override)
+	require.NoError(t, store.CreateVariant(ctx, small))
+	require.NoError(t, store.CreateVariant(ctx, large))
+
+	t.Run("assigns an ID and persists every field", func(t *testing.T) {
+		assert.NotZero(t, small.ID)
+
+		retrieved, err := store.RetrieveVariant(ctx, shirt.ID, large.ID)
+		require.NoError(t, err)
+		assert.Equal(t, shirt.ID, retrieved.ProductID)
+		assert.Equal(t, "SHIRT-L-BLUE", retrieved.SKU)
+		assert.Equal(t, "L", retrieved.Size)
+		assert.Equal(t, "blue", retrieved.Color)
+		assert.Equal(t, 5, retrieved.Quantity)
+		require.NotNil(t, retrieved.Price)
+		assert.Equal(t, amount(2499), *retrieved.Price)
+		assert.WithinDuration(t, large.CreatedAt, retrieved.CreatedAt, timestampTolerance)
+	})
+
+	t.Run("keeps variants without a price override at the product's price", func(t *testing.T) {
+		retrieved, err := store.RetrieveVariant(ctx, shirt.ID, small.ID)
+		require.NoError(t, err)
+		assert.Nil(t, retrieved.Price)
+	})
+
+	t.Run("lists the variants of the product ordered by ID", func(t *testing.T) {
+		variants, err := store.RetrieveVariants(ctx, shirt.ID)
+		require.NoError(t, err)
+		require.Len(t, variants, 2)
+		assert.Equal(t, small.ID, variants[0].ID)
+		assert.Equal(t, large.ID, variants[1].ID)
+
+		_, err = store.RetrieveVariants(ctx, service.ProductID(999999))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("rejects duplicate SKUs", func(t *testing.T) {
+		assert.ErrorIs(t, store.CreateVariant(ctx, variant("SHIRT-S-BLUE", "S", 1, nil)), storage.ErrConflict)
+
+		duplicate := *large
+		duplicate.SKU = small.SKU
+		assert.ErrorIs(t, store.UpdateVariant(ctx, &duplicate), storage.ErrConflict)
+	})
+
+	t.Run("rejects variants of unknown products", func(t *testing.T) {
+		orphan := variant("ORPHAN", "M", 1, nil)
+		orphan.ProductID = service.ProductID(999999)
+		assert.ErrorIs(t, store.CreateVariant(ctx, orphan), storage.ErrNotFound)
+	})
+
+	t.Run("updates the details but not the stock", func(t *testing.T) {
+		updated := *large
+		updated.Size = "XL"
+		updated.Price = nil
+		updated.Quantity = 99
+		require.NoError(t, store.UpdateVariant(ctx, &updated))
+		assert.Equal(t, "XL", updated.Size)
+		assert.Nil(t, updated.Price)
+		assert.Equal(t, 5, updated.Quantity)
+
+		retrieved, err := store.RetrieveVariant(ctx, shirt.ID, large.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "XL", retrieved.Size)
+		assert.Nil(t, retrieved.Price)
+		assert.Equal(t, 5, retrieved.Quantity)
+	})
+
+	t.Run("adjusts the stock without touching the product", func(t *testing.T) {
+		adjusted, err := store.AdjustVariantStock(ctx, shirt.ID, small.ID, -4)
+		require.NoError(t, err)
+		assert.Equal(t, 6, adjusted.Quantity)
+
+		retrieved, err := store.Retrieve(ctx, shirt.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retrieved.Quantity)
+	})
+
+	t.Run("rejects removals exceeding the stock", func(t *testing.T) {
+		_, err := store.AdjustVariantStock(ctx, shirt.ID, small.ID, -7)
+		assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+
+		var stockErr *storage.InsufficientStockError
+		if assert.ErrorAs(t, err, &stockErr) {
+			assert.Equal(t, small.ID, stockErr.VariantID)
+			assert.Equal(t, 7, stockErr.Requested)
+			assert.Equal(t, 6, stockErr.Available)
+		}
+
+		retrieved, err := store.RetrieveVariant(ctx, shirt.ID, small.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 6, retrieved.Quantity)
+	})
+
+	t.Run("never oversells under concurrent removals", func(t *testing.T) {
+		popular := variant("SHIRT-M-BLUE", "M", 50, nil)
+		require.NoError(t, store.CreateVariant(ctx, popular))
+
+		var succeeded atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := store.AdjustVariantStock(ctx, shirt.ID, popular.ID, -5); err == nil {
+					succeeded.Add(1)
+				} else {
+					assert.ErrorIs(t, err, storage.ErrInsufficientStock)
+				}
+			}()
+		}
+		wg.Wait()
+
+		retrieved, err := store.RetrieveVariant(ctx, shirt.ID, popular.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(10), succeeded.Load())
+		assert.Equal(t, 0, retrieved.Quantity)
+	})
+
+	t.Run("fails for variants of another product", func(t *testing.T) {
+		other := CreateProduct(t, store, newPayload("Trousers", 0))
+
+		_, err := store.RetrieveVariant(ctx, other.ID, small.ID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = store.AdjustVariantStock(ctx, other.ID, small.ID, 1)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		assert.ErrorIs(t, store.DeleteVariant(ctx, other.ID, small.ID), storage.ErrNotFound)
+	})
+
+	t.Run("deletes a variant", func(t *testing.T) {
+		require.NoError(t, store.DeleteVariant(ctx, shirt.ID, small.ID))
+
+		_, err := store.RetrieveVariant(ctx, shirt.ID, small.ID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		assert.ErrorIs(t, store.DeleteVariant(ctx, shirt.ID, small.ID), storage.ErrNotFound)
+	})
+
+	t.Run("drops the variants of deleted products", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, shirt))
+
+		_, err := store.RetrieveVariant(ctx, shirt.ID, large.ID)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
+
+func testAttributes(t *testing.T, store storage.ProductStore) {
+	ctx := context.Background()
+
+	newAttributedPayload := func(name string, attributes service.Attributes) *service.ProductCreationPayload {
+		payload := newPayload(name, 1)
+		payload.Attributes = attributes
+		return payload
+	}
+
+	lamp := CreateProduct(t, store, newAttributedPayload("Lamp", service.Attributes{"color": "red", "voltage": 230.0, "wireless": false}))
+	speaker := CreateProduct(t, store, newAttributedPayload("Speaker", service.Attributes{"color": "red", "voltage": 5.0, "wireless": true}))
+	scale := CreateProduct(t, store, newAttributedPayload("Scale", service.Attributes{"color": "black", "weight": 1.25}))
+	plain := CreateProduct(t, store, newPayload("Plain", 1))
+
+	t.Run("persists the attributes", func(t *testing.T) {
+		retrieved, err := store.Retrieve(ctx, lamp.ID)
+		require.NoError(t, err)
+		assert.Equal(t, service.Attributes{"color": "red", "voltage": 230.0, "wireless": false}, retrieved.Attributes)
+
+		retrieved, err = store.Retrieve(ctx, plain.ID)
+		require.NoError(t, err)
+		assert.Empty(t, retrieved.Attributes)
+	})
+
+	t.Run("filters products by attribute", func(t *testing.T) {
+		for _, test := range []struct {
+			attributes map[string]string
+			expected   []service.ProductID
+		}{
+			{map[string]string{"color": "red"}, []service.ProductID{lamp.ID, speaker.ID}},
+			{map[string]string{"color": "red", "wireless": "true"}, []service.ProductID{speaker.ID}},
+			{map[string]string{"wireless": "false"}, []service.ProductID{lamp.ID}},
+			{map[string]string{"voltage": "230"}, []service.ProductID{lamp.ID}},
+			{map[string]string{"weight": "1.25"}, []service.ProductID{scale.ID}},
+			{map[string]string{"color": "Red"}, []service.ProductID{}},
+			{map[string]string{"material": "wood"}, []service.ProductID{}},
+		} {
+			query := &service.ProductQuery{Page: 1, Limit: 10, Filter: service.ProductFilter{Attributes: test.attributes}}
+			products, err := store.RetrieveAll(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, productIDs(products), "attributes %v", test.attributes)
+
+			count, err := store.Count(ctx, query)
+			require.NoError(t, err)
+			assert.Equal(t, len(test.expected), count, "attributes %v", test.attributes)
+		}
+	})
+
+	t.Run("replaces the attributes on update", func(t *testing.T) {
+		scale.Attributes = service.Attributes{"material": "glass"}
+		require.NoError(t, store.Update(ctx, &scale))
+		assert.Equal(t, service.Attributes{"material": "glass"}, scale.Attributes)
+
+		query := &service.ProductQuery{Page: 1, Limit: 10, Filter: service.ProductFilter{Attributes: map[string]string{"color": "black"}}}
+		products, err := store.RetrieveAll(ctx, query)
+		require.NoError(t, err)
+		assert.Empty(t, products)
+	})
+
+	t.Run("persists the attribute schema of categories", func(t *testing.T) {
+		schema := service.AttributeSchema{
+			"voltage":  {Type: service.AttributeNumber, Required: true},
+			"wireless": {Type: service.AttributeBoolean},
+		}
+		category := service.NewCategory(&service.CategoryPayload{Name: "Electronics", AttributeSchema: schema})
+		require.NoError(t, store.CreateCategory(ctx, category))
+
+		retrieved, err := store.RetrieveCategory(ctx, category.ID)
+		require.NoError(t, err)
+		assert.Equal(t, schema, retrieved.AttributeSchema)
+
+		require.NoError(t, store.SetProductCategories(ctx, lamp.ID, []service.CategoryID{category.ID}))
+		categories, err := store.RetrieveProductCategories(ctx, lamp.ID)
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, schema, categories[0].AttributeSchema)
+
+		service.UpdateCategory(retrieved, &service.CategoryPayload{Name: "Electronics"})
+		require.NoError(t, store.UpdateCategory(ctx, retrieved))
+
+		retrieved, err = store.RetrieveCategory(ctx, category.ID)
+		require.NoError(t, err)
+		assert.Empty(t, retrieved.AttributeSchema)
+	})
+
+	t.Run("rejects schemas the linked products do not conform to", func(t *testing.T) {
+		category := service.NewCategory(&service.CategoryPayload{Name: "Lighting"})
+		require.NoError(t, store.CreateCategory(ctx, category))
+		require.NoError(t, store.SetProductCategories(ctx, lamp.ID, []service.CategoryID{category.ID}))
+		require.NoError(t, store.SetProductCategories(ctx, speaker.ID, []service.CategoryID{category.ID}))
+
+		service.UpdateCategory(category, &service.CategoryPayload{Name: "Lighting", AttributeSchema: service.AttributeSchema{
+			"voltage":  {Type: service.AttributeNumber, Required: true},
+			"material": {Type: service.AttributeString, Required: true},
+		}})
+		err := store.UpdateCategory(ctx, category)
+		assert.ErrorIs(t, err, storage.ErrNonconformingAttributes)
+
+		var attributesErr *storage.NonconformingAttributesError
+		require.ErrorAs(t, err, &attributesErr)
+		assert.Equal(t, lamp.ID, attributesErr.ProductID)
+		assert.Equal(t, []service.AttributeError{{Name: "material", Tag: "required", Type: "string"}}, attributesErr.Errors)
+
+		retrieved, err := store.RetrieveCategory(ctx, category.ID)
+		require.NoError(t, err)
+		assert.Empty(t, retrieved.AttributeSchema)
+
+		schema := service.AttributeSchema{"voltage": {Type: service.AttributeNumber, Required: true}}
+		service.UpdateCategory(category, &service.CategoryPayload{Name: "Lighting", AttributeSchema: schema})
+		require.NoError(t, store.UpdateCategory(ctx, category))
+
+		retrieved, err = store.RetrieveCategory(ctx, category.ID)
+		require.NoError(t, err)
+		assert.Equal(t, schema, retrieved.AttributeSchema)
+	})
+
+	t.Run("rejects links to categories the product does not conform to", func(t *testing.T) {
+		category := service.NewCategory(&service.CategoryPayload{Name: "Appliances", AttributeSchema: service.AttributeSchema{
+			"color":  {Type: service.AttributeNumber},
+			"weight": {Type: service.AttributeNumber, Required: true},
+		}})
+		require.NoError(t, store.CreateCategory(ctx, category))
+
+		err := store.SetProductCategories(ctx, speaker.ID, []service.CategoryID{category.ID})
+		assert.ErrorIs(t, err, storage.ErrNonconformingAttributes)
+
+		var attributesErr *storage.NonconformingAttributesError
+		require.ErrorAs(t, err, &attributesErr)
+		assert.Equal(t, speaker.ID, attributesErr.ProductID)
+		assert.Equal(t, []service.AttributeError{
+			{Name: "color", Tag: "type", Type: "number"},
+			{Name: "weight", Tag: "required", Type: "number"},
+		}, attributesErr.Errors)
+
+		categories, err := store.RetrieveProductCategories(ctx, speaker.ID)
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, "Lighting", categories[0].Name)
+	})
+
+	t.Run("rejects updates with attributes the categories do not accept", func(t *testing.T) {
+		updated, err := store.Retrieve(ctx, speaker.ID)
+		require.NoError(t, err)
+
+		// The speaker is linked to Lighting, whose schema requires a numeric voltage.
+		service.UpdateProduct(updated, &service.ProductUpdatePayload{
+			Price: service.Money{Amount: -1}, ID: speaker.ID, Quantity: -1, Discount: -1, Name: "Renamed Speaker",
+			Attributes: service.Attributes{"voltage": "high"},
+		})
+		err = store.Update(ctx, &updated)
+		assert.ErrorIs(t, err, storage.ErrNonconformingAttributes)
+
+		var attributesErr *storage.NonconformingAttributesError
+		require.ErrorAs(t, err, &attributesErr)
+		assert.Equal(t, speaker.ID, attributesErr.ProductID)
+		assert.Equal(t, []service.AttributeError{{Name: "voltage", Tag: "type", Type: "number"}}, attributesErr.Errors)
+
+		retrieved, err := store.Retrieve(ctx, speaker.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Speaker", retrieved.Name)
+		assert.Equal(t, service.Attributes{"color": "red", "voltage": 5.0, "wireless": true}, retrieved.Attributes)
+	})
+}
+
+func testCanceledContext(t *testing.T, store storage.ProductStore) {
+	product := CreateProduct(t, store, newPayload("Canceled Product", 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("aborts reads", func(t *testing.T) {
+		_, err := store.Retrieve(ctx, product.ID)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = store.RetrieveAll(ctx, &service.ProductQuery{Page: 1, Limit: 10})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = store.Search(ctx, &service.SearchQuery{Text: "canceled"})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("aborts writes", func(t *testing.T) {
+		_, err := store.AdjustStock(ctx, product.ID, -1)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = store.AdjustVariantStock(ctx, product.ID, service.VariantID(1), -1)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		assert.ErrorIs(t, store.Delete(ctx, product), context.Canceled)
+
+		updated := *product
+		updated.Price = amount(999)
+		updatedProduct := &updated
+		assert.ErrorIs(t, store.Update(ctx, &updatedProduct), context.Canceled)
+
+		_, err = store.ApplyDuePrices(ctx, time.Now().UTC())
+		assert.ErrorIs(t, err, context.Canceled)
+
+		retrieved, err := store.Retrieve(context.Background(), product.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 10, retrieved.Quantity)
+		assert.Equal(t, amount(1999), retrieved.Price)
+	})
+}
+
+// amount returns the Money of the specified minor units in the default currency.
+func amount(minorUnits int64) service.Money {
+	return service.Money{Amount: minorUnits, Currency: service.DefaultCurrency}
+}
+
+// productIDs returns the IDs of the products, preserving their order.
+func productIDs(products []*service.Product) []service.ProductID {
+	ids := []service.ProductID{}
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	return ids
+}
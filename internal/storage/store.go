@@ -1,14 +1,26 @@
 package storage
 
 import (
+	"context"
+	"fmt"
 	"ntsiris/product-microservice/internal/config"
 	"ntsiris/product-microservice/internal/service"
 )
 
-// ProductStore is an interface that extends the ProductCRUDer interface with additional methods
-// for initializing, verifying, and managing the lifecycle of the product data store.
+// ProductStore is an interface that extends the ProductCRUDer, StockAdjuster, ProductSearcher, ExchangeRateManager,
+// PriceHistorian, PriceScheduler, CategoryCRUDer, ProductCategorizer, TagCounter and VariantManager interfaces with
+// additional methods for initializing, verifying, and managing the lifecycle of the product data store.
 type ProductStore interface {
-	service.ProductCRUDer // Embeds CRUD operations for managing product records.
+	service.ProductCRUDer       // Embeds CRUD operations for managing product records.
+	service.StockAdjuster       // Embeds atomic stock adjustments of product records.
+	service.ProductSearcher     // Embeds full-text searches over product records.
+	service.ExchangeRateManager // Embeds the management of the exchange rates between currencies.
+	service.PriceHistorian      // Embeds the price history of product records.
+	service.PriceScheduler      // Embeds scheduled price changes of product records.
+	service.CategoryCRUDer      // Embeds CRUD operations for managing the category tree.
+	service.ProductCategorizer  // Embeds the links between product records and categories.
+	service.TagCounter          // Embeds the usage counts of the tags of product records.
+	service.VariantManager      // Embeds the variants of product records and their stock.
 
 	// InitStore initializes the connection to the product data store using the provided configuration.
 	//
@@ -21,9 +33,23 @@ type ProductStore interface {
 
 	// VerifyStoreConnection checks the connection to the product data store to ensure it's accessible.
 	//
+	// Parameters:
+	// - ctx: The context bounding the duration of the check.
+	//
 	// Returns:
 	// - An error if the store connection verification fails; otherwise, nil.
-	VerifyStoreConnection() error
+	VerifyStoreConnection(context.Context) error
+
+	// MigrationVersion reports the schema migration the product data store is currently at.
+	//
+	// Parameters:
+	// - ctx: The context bounding the duration of the lookup.
+	//
+	// Returns:
+	// - The version of the last applied migration, or 0 if no migration has been applied.
+	// - True if the last migration failed midway and left the schema dirty; otherwise, false.
+	// - An error if the migration state cannot be read; otherwise, nil.
+	MigrationVersion(context.Context) (uint, bool, error)
 
 	// Close terminates the connection to the product data store, releasing any held resources.
 	//
@@ -51,3 +77,24 @@ type ProductStore interface {
 	// - An error if the migration rollback fails; otherwise, nil.
 	RunMigrationDown(string) error
 }
+
+// NewProductStore creates an uninitialized ProductStore for the specified storage driver.
+//
+// Parameters:
+// - driver: The storage (database) driver name (e.g., "mysql", "postgres" or "sqlite").
+//
+// Returns:
+// - A ProductStore implementation for the driver and nil if the driver is supported.
+// - An error if no ProductStore implementation exists for the driver.
+func NewProductStore(driver string) (ProductStore, error) {
+	switch driver {
+	case SQL_DRIVER:
+		return &MySQLStore{}, nil
+	case POSTGRES_DRIVER:
+		return &PostgresStore{}, nil
+	case SQLITE_DRIVER:
+		return &SQLiteStore{}, nil
+	}
+
+	return nil, fmt.Errorf("error: unsupported storage driver %q", driver)
+}
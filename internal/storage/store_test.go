@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductStore(t *testing.T) {
+	t.Run("selects the store matching the driver", func(t *testing.T) {
+		store, err := NewProductStore("mysql")
+		assert.NoError(t, err)
+		assert.IsType(t, &MySQLStore{}, store)
+
+		store, err = NewProductStore("postgres")
+		assert.NoError(t, err)
+		assert.IsType(t, &PostgresStore{}, store)
+
+		store, err = NewProductStore("sqlite")
+		assert.NoError(t, err)
+		assert.IsType(t, &SQLiteStore{}, store)
+	})
+
+	t.Run("fails for an unsupported driver", func(t *testing.T) {
+		store, err := NewProductStore("oracle")
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+}
+
+func TestRebind(t *testing.T) {
+	query := `UPDATE products SET name = ? WHERE id = ? AND quantity + ? >= 0`
+
+	t.Run("keeps question mark placeholders", func(t *testing.T) {
+		store := &sqlStore{dialect: dialect{}}
+		assert.Equal(t, query, store.rebind(query))
+	})
+
+	t.Run("numbers placeholders", func(t *testing.T) {
+		store := &sqlStore{dialect: dialect{numberedPlaceholders: true}}
+		assert.Equal(t, `UPDATE products SET name = $1 WHERE id = $2 AND quantity + $3 >= 0`, store.rebind(query))
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Run("wraps recognized errors with their sentinel", func(t *testing.T) {
+		assert.ErrorIs(t, classifyError(sql.ErrNoRows, nil), ErrNotFound)
+		assert.ErrorIs(t, classifyError(driver.ErrBadConn, nil), ErrUnavailable)
+		assert.ErrorIs(t, classifyError(fmt.Errorf("query: %w", sql.ErrConnDone), nil), ErrUnavailable)
+	})
+
+	t.Run("treats exceeded deadlines as unavailable", func(t *testing.T) {
+		err := classifyError(context.DeadlineExceeded, nil)
+		assert.ErrorIs(t, err, ErrUnavailable)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("prefers the dialect classification", func(t *testing.T) {
+		err := classifyError(driver.ErrBadConn, func(error) error { return ErrConflict })
+
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+	})
+
+	t.Run("keeps unknown errors untouched", func(t *testing.T) {
+		err := errors.New("syntax error")
+
+		assert.Equal(t, err, classifyError(err, func(error) error { return nil }))
+		assert.Nil(t, classifyError(nil, nil))
+	})
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"ntsiris/product-microservice/internal/service"
+)
+
+// RetrieveTagCounts retrieves every tag carried by at least one product, along with the number of products
+// carrying it, the most used first, ties being ordered by tag.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+//
+// Returns:
+// - A slice of TagCount pointers and nil if successful.
+// - An error if the retrieval fails.
+func (store *sqlStore) RetrieveTagCounts(ctx context.Context) ([]*service.TagCount, error) {
+	query := `SELECT tag, COUNT(*) FROM product_tags GROUP BY tag ORDER BY COUNT(*) DESC, tag`
+	rows, err := store.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var tagCounts []*service.TagCount
+	for rows.Next() {
+		tagCount := new(service.TagCount)
+		if err := rows.Scan(&tagCount.Tag, &tagCount.Count); err != nil {
+			return nil, store.classify(err)
+		}
+
+		tagCounts = append(tagCounts, tagCount)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return tagCounts, nil
+}
+
+// loadTags reads the tags of the products with a single query, setting the Tags of every product.
+// It must not be called while rows of the connection pool are open, as stores may have a single connection.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - products: The products whose tags are read.
+//
+// Returns:
+// - An error if the read fails; otherwise, nil.
+func (store *sqlStore) loadTags(ctx context.Context, products []*service.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	byID := make(map[service.ProductID]*service.Product, len(products))
+	args := make([]any, len(products))
+	for i, product := range products {
+		product.Tags = []string{}
+		byID[product.ID] = product
+		args[i] = product.ID
+	}
+
+	query := `SELECT productID, tag FROM product_tags WHERE productID IN (` + placeholders(len(args)) + `) ORDER BY tag`
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return store.classify(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id service.ProductID
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return store.classify(err)
+		}
+
+		byID[id].Tags = append(byID[id].Tags, tag)
+	}
+
+	return store.classify(rows.Err())
+}
+
+// saveTags replaces the tags of a product within a transaction.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - tx: The transaction the product is written in.
+// - id: The unique ProductID of the product.
+// - tags: The tags of the product, normalized before they are stored.
+//
+// Returns:
+// - An error if the tags cannot be written; otherwise, nil.
+func (store *sqlStore) saveTags(ctx context.Context, tx *sql.Tx, id service.ProductID, tags []string) error {
+	if _, err := tx.ExecContext(ctx, store.rebind(`DELETE FROM product_tags WHERE productID = ?`), id); err != nil {
+		return store.classify(err)
+	}
+
+	for _, tag := range service.NormalizeTags(tags) {
+		if _, err := tx.ExecContext(ctx, store.rebind(`INSERT INTO product_tags (productID, tag) VALUES (?, ?)`), id, tag); err != nil {
+			return store.classify(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"ntsiris/product-microservice/internal/service"
+	"time"
+)
+
+// variantColumns lists the variants table columns in the order expected by queryVariants.
+const variantColumns = `id, productID, sku, size, color, price, currency, quantity, createdAt, lastUpdated`
+
+// CreateVariant inserts a new variant of a product into the database and updates the provided variant with its generated ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - variant: A pointer to the Variant to insert, updated with its ID.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product does not exist.
+// - An error wrapping ErrConflict if another variant has the same SKU, or an error if the insertion fails; otherwise, nil.
+func (store *sqlStore) CreateVariant(ctx context.Context, variant *service.Variant) error {
+	return store.inTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := store.lockPrice(ctx, tx, variant.ProductID); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO variants (productID, sku, size, color, price, currency, quantity, createdAt, lastUpdated) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		id, err := store.insert(ctx, tx, query, variant.ProductID, variant.SKU, variant.Size, variant.Color,
+			variant.Price, variantCurrency(variant), variant.Quantity, variant.CreatedAt, variant.LastUpdated)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		variant.ID = service.VariantID(id)
+		return nil
+	})
+}
+
+// RetrieveVariants retrieves the variants of a product, ordered by ID.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productID: The unique ProductID of the product.
+//
+// Returns:
+// - A slice of Variant pointers and nil if successful.
+// - An error wrapping ErrNotFound if the product does not exist, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveVariants(ctx context.Context, productID service.ProductID) ([]*service.Variant, error) {
+	if _, err := store.Retrieve(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	return store.queryVariants(ctx, `SELECT `+variantColumns+` FROM variants WHERE productID = ? ORDER BY id`, productID)
+}
+
+// RetrieveVariant fetches a variant of a product by its unique ID from the database.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productID: The unique ProductID of the product the variant belongs to.
+// - id: The unique VariantID of the variant to retrieve.
+//
+// Returns:
+// - A pointer to the retrieved Variant and nil if successful.
+// - An error wrapping ErrNotFound if the product has no such variant, or an error if the retrieval fails.
+func (store *sqlStore) RetrieveVariant(ctx context.Context, productID service.ProductID, id service.VariantID) (*service.Variant, error) {
+	variants, err := store.queryVariants(ctx, `SELECT `+variantColumns+` FROM variants WHERE id = ? AND productID = ?`, id, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("error: variant with id %d of product with id %d: %w", id, productID, ErrNotFound)
+	}
+
+	return variants[0], nil
+}
+
+// UpdateVariant replaces the SKU, size, color and price of an existing variant, leaving its quantity unchanged.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - variant: A pointer to the Variant holding the new details, updated with the stored variant.
+//
+// Returns:
+// - An error wrapping ErrConflict if another variant has the same SKU.
+// - An error wrapping ErrNotFound if the product has no such variant, or an error if the update fails; otherwise, nil.
+func (store *sqlStore) UpdateVariant(ctx context.Context, variant *service.Variant) error {
+	query := `UPDATE variants SET sku = ?, size = ?, color = ?, price = ?, currency = ?, lastUpdated = ? WHERE id = ? AND productID = ?`
+	result, err := store.db.ExecContext(ctx, store.rebind(query), variant.SKU, variant.Size, variant.Color,
+		variant.Price, variantCurrency(variant), variant.LastUpdated, variant.ID, variant.ProductID)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("error: variant with id %d of product with id %d: %w", variant.ID, variant.ProductID, ErrNotFound)
+	}
+
+	updated, err := store.RetrieveVariant(ctx, variant.ProductID, variant.ID)
+	if err != nil {
+		return fmt.Errorf("error: could not retrieve updated variant: %w", err)
+	}
+
+	*variant = *updated
+	return nil
+}
+
+// DeleteVariant removes a variant of a product from the database.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productID: The unique ProductID of the product the variant belongs to.
+// - id: The unique VariantID of the variant to delete.
+//
+// Returns:
+// - An error wrapping ErrNotFound if the product has no such variant, an error if the deletion fails; otherwise, nil.
+func (store *sqlStore) DeleteVariant(ctx context.Context, productID service.ProductID, id service.VariantID) error {
+	result, err := store.db.ExecContext(ctx, store.rebind(`DELETE FROM variants WHERE id = ? AND productID = ?`), id, productID)
+	if err != nil {
+		return store.classify(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return store.classify(err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("error: variant with id %d of product with id %d: %w", id, productID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// AdjustVariantStock atomically adds a signed delta to a variant's quantity, provided the stock stays non-negative,
+// with the same guarded update AdjustStock applies to products, within a transaction holding the variant row.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - productID: The unique ProductID of the product the variant belongs to.
+// - id: The unique VariantID of the variant to adjust.
+// - quantityDelta: The number of units to add (positive) or remove (negative).
+//
+// Returns:
+// - A pointer to the adjusted Variant and nil if successful.
+// - An *InsufficientStockError if the delta exceeds the available stock.
+// - An error wrapping ErrConflict if the adjustment was skipped for any other reason.
+// - An error wrapping ErrNotFound if the product has no such variant, or an error if the adjustment fails.
+func (store *sqlStore) AdjustVariantStock(ctx context.Context, productID service.ProductID, id service.VariantID, quantityDelta int) (*service.Variant, error) {
+	err := store.inTransaction(ctx, func(tx *sql.Tx) error {
+		lockQuery := `SELECT quantity FROM variants WHERE id = ? AND productID = ?`
+		if store.dialect.lockingReads {
+			lockQuery += ` FOR UPDATE`
+		}
+
+		var available int
+		err := tx.QueryRowContext(ctx, store.rebind(lockQuery), id, productID).Scan(&available)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("error: variant with id %d of product with id %d: %w", id, productID, ErrNotFound)
+		}
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if available+quantityDelta < 0 {
+			return &InsufficientStockError{ProductID: productID, VariantID: id, Requested: -quantityDelta, Available: available}
+		}
+
+		query := `UPDATE variants SET quantity = quantity + ?, lastUpdated = ? WHERE id = ? AND productID = ? AND quantity + ? >= 0`
+		result, err := tx.ExecContext(ctx, store.rebind(query), quantityDelta, time.Now().UTC(), id, productID, quantityDelta)
+		if err != nil {
+			return store.classify(err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return store.classify(err)
+		}
+
+		if rowsAffected == 0 {
+			return fmt.Errorf("error: variant with id %d of product with id %d: write skipped by a concurrent change: %w", id, productID, ErrConflict)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := store.RetrieveVariant(ctx, productID, id)
+	if err != nil {
+		return nil, fmt.Errorf("error: could not retrieve adjusted variant: %w", err)
+	}
+
+	return variant, nil
+}
+
+// queryVariants runs a query selecting the variantColumns and scans every resulting variant.
+//
+// Parameters:
+// - ctx: The context controlling cancellation and deadline of the database operations.
+// - query: The query, written with ? placeholders.
+// - args: The values bound to the query placeholders.
+//
+// Returns:
+// - A slice of Variant pointers and nil if successful.
+// - An error if the query or scanning fails.
+func (store *sqlStore) queryVariants(ctx context.Context, query string, args ...any) ([]*service.Variant, error) {
+	rows, err := store.db.QueryContext(ctx, store.rebind(query), args...)
+	if err != nil {
+		return nil, store.classify(err)
+	}
+	defer rows.Close()
+
+	var variants []*service.Variant
+	for rows.Next() {
+		var price sql.Null[service.Money]
+		var currency sql.NullString
+		variant := new(service.Variant)
+		err := rows.Scan(&variant.ID, &variant.ProductID, &variant.SKU, &variant.Size, &variant.Color,
+			&price, &currency, &variant.Quantity, &variant.CreatedAt, &variant.LastUpdated)
+		if err != nil {
+			return nil, store.classify(err)
+		}
+
+		if price.Valid {
+			price.V.Currency = service.Currency(currency.String)
+			variant.Price = &price.V
+		}
+
+		variants = append(variants, variant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, store.classify(err)
+	}
+
+	return variants, nil
+}
+
+// variantCurrency returns the currency stored along with the price override of a variant, or nil without an override.
+func variantCurrency(variant *service.Variant) any {
+	if variant.Price == nil {
+		return nil
+	}
+
+	return variant.Price.Currency
+}
@@ -1,35 +1,73 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+)
 
-// APIError represents a structured error message for API responses.
-// It contains the error code, message, the operation during which the error occurred,
-// and any embedded error details for deeper debugging.
+// ProblemContentType is the media type of error responses, as defined by RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// APIError represents a structured error response following RFC 7807 (Problem Details for HTTP APIs).
+// Besides the standard members, it lists the failing fields of rejected payloads and any structured details
+// of the error. The underlying cause is kept for logging and never sent to clients.
 type APIError struct {
-	Code          int    `json:"code"`          // Code is the HTTP status code associated with the error.
-	Message       string `json:"message"`       // Message is a human-readable description of the error.
-	Operation     string `json:"operation"`     // Operation specifies the HTTP method and path where the error occurred.
-	EmbeddedError string `json:"embeddedError"` // EmbeddedError provides additional error information, if available.
+	Type      string       `json:"type"`                // Type is a URI reference identifying the problem type ("about:blank" for plain HTTP errors).
+	Title     string       `json:"title"`               // Title is a short summary of the problem type, the status text for "about:blank".
+	Status    int          `json:"status"`              // Status is the HTTP status code associated with the error.
+	Detail    string       `json:"detail,omitempty"`    // Detail is a human-readable explanation specific to this occurrence of the problem.
+	Instance  string       `json:"instance,omitempty"`  // Instance is the path of the request where the error occurred.
+	Errors    []FieldError `json:"errors,omitempty"`    // Errors lists the fields of the request payload that failed validation, if any.
+	Details   any          `json:"details,omitempty"`   // Details holds structured information about the error, if available.
+	RequestID string       `json:"requestId,omitempty"` // RequestID identifies the failed request in the server logs.
+	Cause     error        `json:"-"`                   // Cause is the underlying error, if any, which is not exposed to clients.
+}
+
+// FieldError describes a single field of a request payload that failed validation.
+type FieldError struct {
+	Field string `json:"field"`           // Field is the JSON path of the failing field (e.g., "price").
+	Tag   string `json:"tag"`             // Tag is the validation rule the field failed (e.g., "required" or "gt").
+	Param string `json:"param,omitempty"` // Param is the parameter of the validation rule, if any (e.g., "0" for "gt=0").
 }
 
-// Error implements the error interface for APIError, returning an empty string.
-// The error message can be customized to include more detail or structured formatting as needed.
+// NewAPIError creates an APIError of the "about:blank" problem type, titled after the HTTP status code.
+//
+// Parameters:
+// - status: The HTTP status code of the error.
+// - detail: A human-readable explanation of the error.
+// - instance: The path of the request where the error occurred.
+// - cause: The underlying error, if any, kept for logging only.
 //
 // Returns:
-// - A string representing the error message (currently empty).
-func (err *APIError) Error() string {
-	return ""
+// - A pointer to the newly created APIError.
+func NewAPIError(status int, detail, instance string, cause error) *APIError {
+	return &APIError{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: instance,
+		Cause:    cause,
+	}
 }
 
-// FormatOperation formats the HTTP method and path into a single string representing the operation.
-// This function is useful for creating the Operation field in APIError.
+// Error implements the error interface for APIError, describing the status, the detail and the underlying cause.
 //
-// Parameters:
-// - method: The HTTP method (e.g., "GET", "POST").
-// - path: The HTTP path where the operation is performed.
+// Returns:
+// - A string representing the error message.
+func (err *APIError) Error() string {
+	message := fmt.Sprintf("%d %s: %s", err.Status, err.Title, err.Detail)
+	if err.Cause != nil {
+		message = fmt.Sprintf("%s: %v", message, err.Cause)
+	}
+
+	return message
+}
+
+// Unwrap returns the underlying cause of the APIError, allowing errors.Is and errors.As to inspect it.
 //
 // Returns:
-// - A string combining the method and path in the format "METHOD /path".
-func FormatOperation(method, path string) string {
-	return fmt.Sprintf("%s %s", method, path)
+// - The underlying error, or nil if there is none.
+func (err *APIError) Unwrap() error {
+	return err.Cause
 }
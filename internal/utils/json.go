@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"ntsiris/product-microservice/internal/types"
 )
 
 // ParseJSON decodes a JSON payload from an HTTP request into the provided structure.
@@ -32,7 +33,25 @@ func ParseJSON(r *http.Request, payload any) error {
 // Returns:
 // - An error if encoding the value as JSON fails; otherwise, nil.
 func WriteJSON(w http.ResponseWriter, status int, value any) error {
-	w.Header().Set("Content-Type", "application/json")
+	return writeJSON(w, status, "application/json", value)
+}
+
+// WriteProblem encodes an RFC 7807 problem details value as JSON and writes it to the HTTP response
+// with the application/problem+json content type and the specified status code.
+//
+// Parameters:
+// - w: The http.ResponseWriter used to write the problem response.
+// - status: The HTTP status code to set for the response (e.g., 400 for Bad Request).
+// - problem: The problem details to be encoded as JSON and sent in the response body.
+//
+// Returns:
+// - An error if encoding the problem as JSON fails; otherwise, nil.
+func WriteProblem(w http.ResponseWriter, status int, problem any) error {
+	return writeJSON(w, status, types.ProblemContentType, problem)
+}
+
+func writeJSON(w http.ResponseWriter, status int, contentType string, value any) error {
+	w.Header().Set("Content-Type", contentType)
 	w.WriteHeader(status)
 
 	return json.NewEncoder(w).Encode(value)
@@ -1,6 +1,56 @@
 package utils
 
-import "github.com/go-playground/validator/v10"
+import (
+	"ntsiris/product-microservice/internal/service"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
 
 // Validate is a globally accessible instance of the validator package, used for struct validation.
-var Validate = validator.New()
+// Validation errors name the fields after their JSON keys, so they can be reported to clients as sent.
+// Money fields are validated by their amount in minor units (e.g., "gt=0" requires a positive amount),
+// the "money" tag accepts the amounts allowed by service.Money.IsStorable, the "currency" tag accepts the service.SupportedCurrencies only, the "tag" tag accepts the product tags
+// allowed by service.IsValidTag, and the "attribute" and "attributevalue" tags accept the attribute names
+// and values allowed by service.IsValidAttributeName and service.IsValidAttributeValue.
+var Validate = newValidator()
+
+func newValidator() *validator.Validate {
+	validate := validator.New()
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(service.Money).Amount
+	}, service.Money{})
+
+	validate.RegisterValidation("money", func(field validator.FieldLevel) bool {
+		return service.Money{Amount: field.Field().Int()}.IsStorable()
+	})
+
+	validate.RegisterValidation("currency", func(field validator.FieldLevel) bool {
+		return service.IsSupportedCurrency(service.Currency(field.Field().String()))
+	})
+
+	validate.RegisterValidation("tag", func(field validator.FieldLevel) bool {
+		return service.IsValidTag(field.Field().String())
+	})
+
+	validate.RegisterValidation("attribute", func(field validator.FieldLevel) bool {
+		return service.IsValidAttributeName(field.Field().String())
+	})
+
+	// Null values are validated too, so they are rejected rather than skipped.
+	validate.RegisterValidation("attributevalue", func(field validator.FieldLevel) bool {
+		return field.Field().IsValid() && service.IsValidAttributeValue(field.Field().Interface())
+	}, true)
+
+	return validate
+}
@@ -0,0 +1,9 @@
+// Package migrations bundles the database migration scripts that are embedded into the application binary.
+package migrations
+
+import "embed"
+
+// SQLite holds the SQLite migration scripts, applied by the embedded SQLite store.
+//
+//go:embed sqlite/*.sql
+var SQLite embed.FS
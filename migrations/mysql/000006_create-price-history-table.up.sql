@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `price_history`(
+    `id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `productID` INT UNSIGNED NOT NULL,
+    `previousPrice` DECIMAL(10,2) NOT NULL,
+    `previousCurrency` CHAR(3) NOT NULL,
+    `price` DECIMAL(10,2) NOT NULL,
+    `currency` CHAR(3) NOT NULL,
+    `changedAt` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `price_history_product` (`productID`, `changedAt`),
+    FOREIGN KEY (`productID`) REFERENCES `products`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `scheduled_prices`(
+    `id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `productID` INT UNSIGNED NOT NULL,
+    `price` DECIMAL(10,2) NOT NULL,
+    `currency` CHAR(3) NOT NULL,
+    `effectiveAt` TIMESTAMP NOT NULL,
+    `createdAt` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX `scheduled_prices_effective` (`effectiveAt`),
+    FOREIGN KEY (`productID`) REFERENCES `products`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `categories`(
+    `id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `name` VARCHAR(255) NOT NULL,
+    `parentID` INT UNSIGNED NULL,
+    `createdAt` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `lastUpdated` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (`parentID`) REFERENCES `categories`(`id`)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `product_categories`(
+    `productID` INT UNSIGNED NOT NULL,
+    `categoryID` INT UNSIGNED NOT NULL,
+    PRIMARY KEY (`productID`, `categoryID`),
+    INDEX `product_categories_category` (`categoryID`),
+    FOREIGN KEY (`productID`) REFERENCES `products`(`id`) ON DELETE CASCADE,
+    FOREIGN KEY (`categoryID`) REFERENCES `categories`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS `product_tags`(
+    `productID` INT UNSIGNED NOT NULL,
+    `tag` VARCHAR(64) NOT NULL,
+    PRIMARY KEY (`productID`, `tag`),
+    INDEX `product_tags_tag` (`tag`),
+    FOREIGN KEY (`productID`) REFERENCES `products`(`id`) ON DELETE CASCADE
+);
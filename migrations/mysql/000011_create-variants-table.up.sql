@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `variants`(
+    `id` INT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    `productID` INT UNSIGNED NOT NULL,
+    `sku` VARCHAR(64) NOT NULL UNIQUE,
+    `size` VARCHAR(64) NOT NULL DEFAULT '',
+    `color` VARCHAR(64) NOT NULL DEFAULT '',
+    `price` DECIMAL(10,2) NULL,
+    `currency` CHAR(3) NULL,
+    `quantity` INT NOT NULL DEFAULT 0,
+    `createdAt` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    `lastUpdated` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (`productID`) REFERENCES `products`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+ALTER TABLE `products` DROP INDEX `products_sku`, ALTER COLUMN `sku` SET DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE `products` ADD UNIQUE INDEX `products_sku` (`sku`), ALTER COLUMN `sku` DROP DEFAULT;
@@ -0,0 +1 @@
+ALTER TABLE `products` ADD COLUMN `attributes` JSON NOT NULL DEFAULT (JSON_OBJECT());
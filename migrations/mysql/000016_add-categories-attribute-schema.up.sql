@@ -0,0 +1 @@
+ALTER TABLE `categories` ADD COLUMN `attributeSchema` JSON NOT NULL DEFAULT (JSON_OBJECT());
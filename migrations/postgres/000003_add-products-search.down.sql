@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS products_search;
+ALTER TABLE products DROP COLUMN searchVector;
@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN searchVector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX products_search ON products USING GIN (searchVector);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS exchange_rates(
+    baseCurrency CHAR(3) NOT NULL,
+    quoteCurrency CHAR(3) NOT NULL,
+    rate NUMERIC(18,6) NOT NULL,
+    updatedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (baseCurrency, quoteCurrency)
+);
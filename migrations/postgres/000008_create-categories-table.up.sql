@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS categories(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    parentID INTEGER NULL REFERENCES categories(id),
+    createdAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    lastUpdated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS categories_parent ON categories(parentID);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS variants(
+    id SERIAL PRIMARY KEY,
+    productID INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    size VARCHAR(64) NOT NULL DEFAULT '',
+    color VARCHAR(64) NOT NULL DEFAULT '',
+    price NUMERIC(10,2),
+    currency CHAR(3),
+    quantity INTEGER NOT NULL DEFAULT 0,
+    createdAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    lastUpdated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS variants_product ON variants(productID);
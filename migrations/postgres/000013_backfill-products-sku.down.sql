@@ -0,0 +1 @@
+UPDATE products SET sku = '';
@@ -0,0 +1,3 @@
+ALTER TABLE products ALTER COLUMN sku SET DEFAULT '';
+
+DROP INDEX IF EXISTS products_sku;
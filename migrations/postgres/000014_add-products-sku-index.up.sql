@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku ON products(sku);
+
+ALTER TABLE products ALTER COLUMN sku DROP DEFAULT;
@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN attributes JSONB NOT NULL DEFAULT '{}';
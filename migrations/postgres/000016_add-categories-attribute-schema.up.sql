@@ -0,0 +1 @@
+ALTER TABLE categories ADD COLUMN attributeSchema JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS products_search_update;
+DROP TRIGGER IF EXISTS products_search_delete;
+DROP TRIGGER IF EXISTS products_search_insert;
+DROP TABLE IF EXISTS products_search;
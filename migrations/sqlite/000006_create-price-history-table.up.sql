@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS price_history(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    productID INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    previousPrice DECIMAL(10,2) NOT NULL,
+    previousCurrency CHAR(3) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    changedAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS price_history_product ON price_history(productID, changedAt);
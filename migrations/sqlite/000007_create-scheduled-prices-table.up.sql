@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS scheduled_prices(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    productID INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(10,2) NOT NULL,
+    currency CHAR(3) NOT NULL,
+    effectiveAt TIMESTAMP NOT NULL,
+    createdAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_prices_effective ON scheduled_prices(effectiveAt);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS product_categories(
+    productID INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    categoryID INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (productID, categoryID)
+);
+
+CREATE INDEX IF NOT EXISTS product_categories_category ON product_categories(categoryID);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS product_tags(
+    productID INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (productID, tag)
+);
+
+CREATE INDEX IF NOT EXISTS product_tags_tag ON product_tags(tag);
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku ON products(sku);
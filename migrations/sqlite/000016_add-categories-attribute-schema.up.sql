@@ -0,0 +1 @@
+ALTER TABLE categories ADD COLUMN attributeSchema TEXT NOT NULL DEFAULT '{}';